@@ -0,0 +1,208 @@
+// Package box encrypts the payloads exchanged between services with X25519 key agreement,
+// e.g. the sensitive fields of a message before it is logged or queued.
+//
+// A sealed box (Seal) is anonymous: it is encrypted with an ephemeral key pair,
+// only the recipient can open it and nothing proves who sealed it.
+// An authenticated box (KeyPair.SealTo) is encrypted with the key pair of the sender,
+// the recipient opens it with the public key of the sender (KeyPair.OpenFrom).
+//
+// The shared key is derived with ECDH and HKDF-SHA256, the payload is encrypted with AES-256-GCM.
+// Each box has a key of its own: an ephemeral key pair (Seal) or a random salt (SealTo) is mixed into HKDF,
+// so the random GCM nonces are never used twice with the same key.
+// The additional data (aad) is authenticated but not encrypted, e.g. the ID of the record the field belongs to,
+// so a box cannot be moved to another record.
+//
+// Example:
+//
+//	str, err := box.SealString(recipient, []byte(cardNumber), []byte(paymentId))
+//	// ...
+//	cardNumber, err := keyPair.OpenString(str, []byte(paymentId))
+package box
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	// Version of the box formats, the first byte of a box
+	versionSealed        byte = 1
+	versionAuthenticated byte = 2
+
+	// HKDF info of the keys of the box formats
+	infoSealed        = "box.sealed.v1"
+	infoAuthenticated = "box.authenticated.v1"
+
+	keySize   = 32
+	nonceSize = 12
+	saltSize  = 32
+)
+
+var (
+	// ErrOpen is returned when a box cannot be opened: it is malformed, tampered, or sealed to another key.
+	ErrOpen = errors.New("box: message authentication failed")
+)
+
+// Seal encrypts the plaintext to the recipient with an ephemeral key pair (anonymous sealed box).
+//
+// The box is: version (1 byte) || ephemeral public key (32 bytes) || nonce (12 bytes) || ciphertext and tag.
+func Seal(recipient *PublicKey, plaintext, aad []byte) ([]byte, error) {
+	if recipient == nil || recipient.PublicKey == nil {
+		return nil, errors.New("recipient public key is nil")
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := ephemeral.PublicKey().Bytes()
+	key, err := deriveKey(ephemeral, recipient.PublicKey, infoSealed, ephemeralPub, recipient.PublicKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header := append([]byte{versionSealed}, ephemeralPub...)
+	return seal(key, header, plaintext, aad)
+}
+
+// Open decrypts a box sealed to the key pair by Seal.
+func (p *KeyPair) Open(box, aad []byte) ([]byte, error) {
+	headerSize := 1 + len(p.PrivateKey.PublicKey().Bytes())
+	if len(box) < headerSize || box[0] != versionSealed {
+		return nil, ErrOpen
+	}
+	ephemeralPub := box[1:headerSize]
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralPub)
+	if err != nil {
+		return nil, ErrOpen
+	}
+	key, err := deriveKey(p.PrivateKey, ephemeral, infoSealed, ephemeralPub, p.PrivateKey.PublicKey().Bytes())
+	if err != nil {
+		return nil, ErrOpen
+	}
+	return open(key, box[:headerSize], box[headerSize:], aad)
+}
+
+// SealTo encrypts the plaintext from the key pair to the recipient (authenticated box).
+// Only the sender and the recipient share the key: the recipient knows the box comes from the sender,
+// but cannot prove it to a third party.
+//
+// The box is: version (1 byte) || salt (32 bytes) || nonce (12 bytes) || ciphertext and tag.
+func (p *KeyPair) SealTo(recipient *PublicKey, plaintext, aad []byte) ([]byte, error) {
+	if recipient == nil || recipient.PublicKey == nil {
+		return nil, errors.New("recipient public key is nil")
+	}
+	// The key of the sender and the recipient is static, the salt makes the key of each box unique
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(p.PrivateKey, recipient.PublicKey, infoAuthenticated, salt, p.PrivateKey.PublicKey().Bytes(), recipient.PublicKey.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header := append([]byte{versionAuthenticated}, salt...)
+	return seal(key, header, plaintext, aad)
+}
+
+// OpenFrom decrypts a box sealed to the key pair by the sender with SealTo.
+func (p *KeyPair) OpenFrom(sender *PublicKey, box, aad []byte) ([]byte, error) {
+	if sender == nil || sender.PublicKey == nil {
+		return nil, errors.New("sender public key is nil")
+	}
+	headerSize := 1 + saltSize
+	if len(box) < headerSize || box[0] != versionAuthenticated {
+		return nil, ErrOpen
+	}
+	salt := box[1:headerSize]
+	key, err := deriveKey(p.PrivateKey, sender.PublicKey, infoAuthenticated, salt, sender.PublicKey.Bytes(), p.PrivateKey.PublicKey().Bytes())
+	if err != nil {
+		return nil, ErrOpen
+	}
+	return open(key, box[:headerSize], box[headerSize:], aad)
+}
+
+// SharedKey derives a 32-byte key shared with the peer, with ECDH and HKDF-SHA256.
+// Both sides get the same key for the same info, e.g. to key a channel or a HMAC.
+func (p *KeyPair) SharedKey(peer *PublicKey, info []byte) ([]byte, error) {
+	if peer == nil || peer.PublicKey == nil {
+		return nil, errors.New("peer public key is nil")
+	}
+	// The salt is the public keys in a canonical order, so both sides derive the same key
+	own, other := p.PrivateKey.PublicKey().Bytes(), peer.PublicKey.Bytes()
+	if slices.Compare(own, other) > 0 {
+		own, other = other, own
+	}
+	return deriveKey(p.PrivateKey, peer.PublicKey, string(info), own, other)
+}
+
+// SealString seals the plaintext to the recipient as Seal does, and encodes the box in base64.
+func SealString(recipient *PublicKey, plaintext, aad []byte) (string, error) {
+	box, err := Seal(recipient, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(box), nil
+}
+
+// OpenString opens a base64 box of SealString.
+func (p *KeyPair) OpenString(str string, aad []byte) ([]byte, error) {
+	box, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode box: %w", err)
+	}
+	return p.Open(box, aad)
+}
+
+// deriveKey derives the AES-256 key from the X25519 shared secret,
+// the salt is the concatenation of the values: the public keys, preceded by the salt of the box in SealTo.
+func deriveKey(key *ecdh.PrivateKey, peer *ecdh.PublicKey, info string, publicKeys ...[]byte) ([]byte, error) {
+	secret, err := key.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	return hkdf.Key(sha256.New, secret, slices.Concat(publicKeys...), info, keySize)
+}
+
+// seal returns header || nonce || ciphertext, the header is authenticated with the aad.
+func seal(key, header, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := slices.Concat(header, nonce)
+	return gcm.Seal(out, nonce, plaintext, slices.Concat(header, aad)), nil
+}
+
+func open(key, header, body, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < nonceSize+gcm.Overhead() {
+		return nil, ErrOpen
+	}
+	plaintext, err := gcm.Open(nil, body[:nonceSize], body[nonceSize:], slices.Concat(header, aad))
+	if err != nil {
+		return nil, ErrOpen
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,178 @@
+package box
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+)
+
+func TestSeal(t *testing.T) {
+	t.Parallel()
+
+	recipient, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, aad := []byte("4111 1111 1111 1111"), []byte("payment-1")
+
+	str, err := SealString(recipient.ToPublic(), plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := recipient.OpenString(str, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("unexpected plaintext %q", got)
+	}
+
+	box, err := Seal(recipient.ToPublic(), plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(box)
+	tampered[len(tampered)-1] ^= 1
+	for name, open := range map[string]func() ([]byte, error){
+		"wrong key":  func() ([]byte, error) { return other.Open(box, aad) },
+		"wrong aad":  func() ([]byte, error) { return recipient.Open(box, []byte("payment-2")) },
+		"tampered":   func() ([]byte, error) { return recipient.Open(tampered, aad) },
+		"truncated":  func() ([]byte, error) { return recipient.Open(box[:40], aad) },
+		"wrong type": func() ([]byte, error) { return recipient.OpenFrom(other.ToPublic(), box, aad) },
+	} {
+		if _, err := open(); !errors.Is(err, ErrOpen) {
+			t.Errorf("%s: expected ErrOpen, got %v", name, err)
+		}
+	}
+}
+
+func TestSealTo(t *testing.T) {
+	t.Parallel()
+
+	sender, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("transfer 100 from A to B")
+
+	box, err := sender.SealTo(recipient.ToPublic(), plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := recipient.OpenFrom(sender.ToPublic(), box, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("unexpected plaintext %q", got)
+	}
+	if _, err := recipient.OpenFrom(other.ToPublic(), box, nil); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen for the wrong sender, got %v", err)
+	}
+
+	// Each box has a salt of its own, hence a key of its own
+	again, err := sender.SealTo(recipient.ToPublic(), plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(again[1:1+saltSize], box[1:1+saltSize]) {
+		t.Fatal("expected a new salt for each box")
+	}
+	tampered := bytes.Clone(box)
+	tampered[1] ^= 1
+	if _, err := recipient.OpenFrom(sender.ToPublic(), tampered, nil); !errors.Is(err, ErrOpen) {
+		t.Fatalf("expected ErrOpen for a tampered salt, got %v", err)
+	}
+
+	// Both sides derive the same shared key
+	k1, err := sender.SharedKey(recipient.ToPublic(), []byte("channel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := recipient.SharedKey(sender.ToPublic(), []byte("channel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(k1, k2) || len(k1) != 32 {
+		t.Fatal("shared keys do not match")
+	}
+}
+
+func TestKeyPair(t *testing.T) {
+	t.Parallel()
+
+	// An ed25519 signing key pair can also receive boxes
+	edKeyPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPair, err := KeyPairFromEd25519(edKeyPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := PublicKeyFromEd25519(edKeyPair.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.PublicKey.Equal(keyPair.ToPublic().PublicKey) {
+		t.Fatal("converted public key does not match")
+	}
+
+	// Round trip through the serialized forms
+	secret, err := keyPair.PKCS8PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSecret, err := KeyPairFromSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM, privatePEM, err := keyPair.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPEM, err := KeyPairFromPEM(privatePEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromSecret.PrivateKey.Equal(keyPair.PrivateKey) || !fromPEM.PrivateKey.Equal(keyPair.PrivateKey) {
+		t.Fatal("loaded key does not match")
+	}
+	pkix, err := keyPair.PKIXPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPKIX, err := PublicKeyFromPKIX(pkix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPublicPEM, err := PublicKeyFromPEM(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromPKIX.PublicKey.Equal(pub.PublicKey) || !fromPublicPEM.PublicKey.Equal(pub.PublicKey) {
+		t.Fatal("loaded public key does not match")
+	}
+
+	box, err := Seal(fromPKIX, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fromPEM.Open(box, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,99 @@
+package box
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+)
+
+func GenerateKeyPair() (*KeyPair, error) {
+	// Generate X25519 key pair
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	return &KeyPair{PrivateKey: key}, nil
+}
+
+// KeyPairFromEd25519 converts an Ed25519 key pair to its X25519 key pair,
+// so a service can sign and receive boxes with a single key.
+func KeyPairFromEd25519(keyPair *edKeys.KeyPair) (*KeyPair, error) {
+	key, err := keyPair.X25519()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{PrivateKey: key}, nil
+}
+
+func KeyPairFromSecret(secret string) (*KeyPair, error) {
+	der, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return keyPairFromPKCS8(der)
+}
+
+func KeyPairFromPEM(privatePEM []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+	if block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	// PKCS8 format
+	return keyPairFromPKCS8(block.Bytes)
+}
+
+func keyPairFromPKCS8(der []byte) (*KeyPair, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	privateKey, ok := key.(*ecdh.PrivateKey)
+	if !ok || privateKey.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	return &KeyPair{
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// KeyPair is a X25519 key pair, to receive the boxes and to send the authenticated boxes.
+type KeyPair struct {
+	PrivateKey *ecdh.PrivateKey
+}
+
+func (p *KeyPair) PKIXPublicKey() (string, error) {
+	return p.ToPublic().PKIXPublicKey()
+}
+
+func (p *KeyPair) PKCS8PrivateKey() (string, error) {
+	bin, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *KeyPair) PEM() (public []byte, private []byte, err error) {
+	o1, err := p.ToPublic().PEM()
+	if err != nil {
+		return nil, nil, err
+	}
+	// PKCS8 marshal private key
+	bin, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	o2 := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: bin,
+	})
+	return o1, o2, nil
+}
@@ -0,0 +1,84 @@
+package box
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+)
+
+// PublicKey is the public part of a KeyPair, the boxes are sealed to it.
+type PublicKey struct {
+	PublicKey *ecdh.PublicKey
+}
+
+// ToPublic returns the public key of the key pair, to hand to the senders.
+func (p *KeyPair) ToPublic() *PublicKey {
+	return &PublicKey{PublicKey: p.PrivateKey.PublicKey()}
+}
+
+// PublicKeyFromEd25519 converts the public key of an Ed25519 key pair to its X25519 public key, see KeyPairFromEd25519.
+func PublicKeyFromEd25519(pub ed25519.PublicKey) (*PublicKey, error) {
+	key, err := edKeys.X25519PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{PublicKey: key}, nil
+}
+
+// PublicKeyFromPKIX loads a public key from its base64 PKIX form, the output of KeyPair.PKIXPublicKey.
+func PublicKeyFromPKIX(pkix string) (*PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(pkix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	return publicKeyFromPKIX(der)
+}
+
+// PublicKeyFromPEM loads a public key from a "PUBLIC KEY" PEM block.
+func PublicKeyFromPEM(publicPEM []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(publicPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+	if block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	return publicKeyFromPKIX(block.Bytes)
+}
+
+func publicKeyFromPKIX(der []byte) (*PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	pub, ok := key.(*ecdh.PublicKey)
+	if !ok || pub.Curve() != ecdh.X25519() {
+		return nil, fmt.Errorf("invalid public key: %T", key)
+	}
+	return &PublicKey{PublicKey: pub}, nil
+}
+
+func (p *PublicKey) PKIXPublicKey() (string, error) {
+	bin, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *PublicKey) PEM() ([]byte, error) {
+	// PKIX marshal public key
+	pkix, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pkix,
+	}), nil
+}
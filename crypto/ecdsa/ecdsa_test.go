@@ -0,0 +1,68 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"testing"
+)
+
+func TestKeyPair(t *testing.T) {
+	t.Parallel()
+
+	data := []byte("transfer 100 from A to B")
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		keyPair, err := GenerateKeyPair(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Round trip through the serialized forms
+		secret, err := keyPair.PKCS8PrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromSecret, err := KeyPairFromSecret(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicPEM, privatePEM, err := keyPair.PEM()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromPEM, err := KeyPairFromPEM(privatePEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fromSecret.PrivateKey.Equal(keyPair.PrivateKey) || !fromPEM.PrivateKey.Equal(keyPair.PrivateKey) {
+			t.Fatalf("%s: loaded key does not match", curve.Params().Name)
+		}
+		pub, err := PublicKeyFromPEM(publicPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sig, err := keyPair.SignASN1(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := pub.VerifyASN1(data, sig); err != nil {
+			t.Fatalf("%s: %v", curve.Params().Name, err)
+		}
+		raw, err := keyPair.SignRaw(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(raw) != 2*curveSize(curve) {
+			t.Fatalf("%s: unexpected raw signature size %d", curve.Params().Name, len(raw))
+		}
+		if err := pub.VerifyRaw(data, raw); err != nil {
+			t.Fatalf("%s: %v", curve.Params().Name, err)
+		}
+		if err := pub.VerifyRaw([]byte("tampered"), raw); err == nil {
+			t.Fatalf("%s: expected an error for tampered data", curve.Params().Name)
+		}
+	}
+
+	if _, err := GenerateKeyPair(elliptic.P224()); err == nil {
+		t.Fatal("expected an error for P-224")
+	}
+}
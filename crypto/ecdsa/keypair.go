@@ -0,0 +1,132 @@
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// GenerateKeyPair generates a key pair on the curve: elliptic.P256(), elliptic.P384() or elliptic.P521().
+func GenerateKeyPair(curve elliptic.Curve) (*KeyPair, error) {
+	if err := checkCurve(curve); err != nil {
+		return nil, err
+	}
+	// Generate ECDSA key pair
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	return &KeyPair{PrivateKey: key}, nil
+}
+
+func KeyPairFromSecret(secret string) (*KeyPair, error) {
+	der, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return keyPairFromPKCS8(der)
+}
+
+func keyPairFromPKCS8(der []byte) (*KeyPair, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return newKeyPair(key)
+}
+
+func KeyPairFromPEM(privatePEM []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		// PKCS8 format
+		return keyPairFromPKCS8(block.Bytes)
+	case "EC PRIVATE KEY":
+		// SEC 1 format
+		parsed, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return newKeyPair(parsed)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+func newKeyPair(key any) (*KeyPair, error) {
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	if err := checkCurve(privateKey.Curve); err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		PrivateKey: privateKey,
+	}, nil
+}
+
+func checkCurve(curve elliptic.Curve) error {
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return nil
+	case nil:
+		return fmt.Errorf("curve is nil")
+	default:
+		return fmt.Errorf("unsupported curve: %s", curve.Params().Name)
+	}
+}
+
+type KeyPair struct {
+	PrivateKey *ecdsa.PrivateKey
+}
+
+func (p *KeyPair) PKIXPublicKey() (string, error) {
+	if p.PrivateKey == nil {
+		return "", fmt.Errorf("private key is nil")
+	}
+	bin, err := x509.MarshalPKIXPublicKey(&p.PrivateKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *KeyPair) PKCS8PrivateKey() (string, error) {
+	bin, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *KeyPair) PEM() (public []byte, private []byte, err error) {
+	// PKIX marshal public key
+	pkix, err := x509.MarshalPKIXPublicKey(&p.PrivateKey.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	// PKCS8 marshal private key
+	bin, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Encode result
+	o1 := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pkix,
+	})
+	o2 := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: bin,
+	})
+	return o1, o2, nil
+}
@@ -0,0 +1,104 @@
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// PublicKey is the public part of a KeyPair.
+// Verification services use it without holding the private key.
+type PublicKey struct {
+	PublicKey *ecdsa.PublicKey
+}
+
+// ToPublic returns the public key of the key pair, to hand to the verification services.
+func (p *KeyPair) ToPublic() *PublicKey {
+	return &PublicKey{PublicKey: &p.PrivateKey.PublicKey}
+}
+
+// PublicKeyFromPKIX loads a public key from its base64 PKIX form, the output of KeyPair.PKIXPublicKey.
+func PublicKeyFromPKIX(pkix string) (*PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(pkix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return newPublicKey(key)
+}
+
+// PublicKeyFromPEM loads a public key from a PEM block: "PUBLIC KEY" (PKIX) or "CERTIFICATE" (X.509).
+func PublicKeyFromPEM(publicPEM []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(publicPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return newPublicKey(key)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return PublicKeyFromCertificate(cert)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+// PublicKeyFromCertificate returns the public key of a X.509 certificate.
+func PublicKeyFromCertificate(cert *x509.Certificate) (*PublicKey, error) {
+	return newPublicKey(cert.PublicKey)
+}
+
+func newPublicKey(key any) (*PublicKey, error) {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key: %T", key)
+	}
+	if err := checkCurve(pub.Curve); err != nil {
+		return nil, err
+	}
+	return &PublicKey{PublicKey: pub}, nil
+}
+
+func (p *PublicKey) PKIXPublicKey() (string, error) {
+	bin, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *PublicKey) PEM() ([]byte, error) {
+	// PKIX marshal public key
+	pkix, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pkix,
+	}), nil
+}
+
+// VerifyASN1 verifies an ASN.1 signature of KeyPair.SignASN1.
+func (p *PublicKey) VerifyASN1(data, signature []byte) error {
+	return verify(p.PublicKey, data, signature)
+}
+
+// VerifyRaw verifies a r||s signature of KeyPair.SignRaw.
+func (p *PublicKey) VerifyRaw(data, signature []byte) error {
+	return verifyRaw(p.PublicKey, data, signature)
+}
@@ -0,0 +1,105 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// SignASN1 signs the data and returns the ASN.1 DER signature (X.509, TLS).
+// The data is hashed with SHA-256, SHA-384 or SHA-512 for P-256, P-384 and P-521.
+func (p *KeyPair) SignASN1(data []byte) ([]byte, error) {
+	d, err := digest(p.PrivateKey.Curve, data)
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.SignASN1(rand.Reader, p.PrivateKey, d)
+}
+
+// SignRaw signs the data and returns the raw r||s signature (JWS, WebAuthn),
+// r and s are padded to the size of the curve.
+func (p *KeyPair) SignRaw(data []byte) ([]byte, error) {
+	d, err := digest(p.PrivateKey.Curve, data)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, p.PrivateKey, d)
+	if err != nil {
+		return nil, err
+	}
+	size := curveSize(p.PrivateKey.Curve)
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+// VerifyASN1 verifies an ASN.1 signature of SignASN1.
+func (p *KeyPair) VerifyASN1(data, signature []byte) error {
+	return verify(&p.PrivateKey.PublicKey, data, signature)
+}
+
+// VerifyRaw verifies a r||s signature of SignRaw.
+func (p *KeyPair) VerifyRaw(data, signature []byte) error {
+	return verifyRaw(&p.PrivateKey.PublicKey, data, signature)
+}
+
+func verify(pub *ecdsa.PublicKey, data, signature []byte) error {
+	d, err := digest(pub.Curve, data)
+	if err != nil {
+		return err
+	}
+	if !ecdsa.VerifyASN1(pub, d, signature) {
+		return errors.New("ecdsa: verification error")
+	}
+	return nil
+}
+
+func verifyRaw(pub *ecdsa.PublicKey, data, signature []byte) error {
+	d, err := digest(pub.Curve, data)
+	if err != nil {
+		return err
+	}
+	size := curveSize(pub.Curve)
+	if len(signature) != 2*size {
+		return errors.New("ecdsa: invalid signature size")
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(pub, d, r, s) {
+		return errors.New("ecdsa: verification error")
+	}
+	return nil
+}
+
+// HashOf returns the hash used with the curve: SHA-256 for P-256, SHA-384 for P-384 and SHA-512 for P-521.
+func HashOf(curve elliptic.Curve) (crypto.Hash, error) {
+	if err := checkCurve(curve); err != nil {
+		return 0, err
+	}
+	switch curve {
+	case elliptic.P384():
+		return crypto.SHA384, nil
+	case elliptic.P521():
+		return crypto.SHA512, nil
+	default:
+		return crypto.SHA256, nil
+	}
+}
+
+func digest(curve elliptic.Curve, data []byte) ([]byte, error) {
+	hash, err := HashOf(curve)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+func curveSize(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
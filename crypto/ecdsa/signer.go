@@ -0,0 +1,54 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"io"
+
+	"github.com/golang-devkit/pkg/crypto/internal/thumbprint"
+)
+
+// Public returns the public key, the key pair is a crypto.Signer.
+func (p *KeyPair) Public() crypto.PublicKey {
+	return &p.PrivateKey.PublicKey
+}
+
+// Sign signs the digest as ecdsa.PrivateKey.Sign does (crypto.Signer), the signature is ASN.1 DER.
+// Use SignASN1 or SignRaw to sign the data with the hash of the curve.
+func (p *KeyPair) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return p.PrivateKey.Sign(rand, digest, opts)
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the default key ID of the jwt package.
+func (p *KeyPair) KeyID() string {
+	return keyID(&p.PrivateKey.PublicKey)
+}
+
+// Algorithm returns the JWA name (RFC 7518) of the signatures: ES256, ES384 or ES512 depends on the curve.
+func (p *KeyPair) Algorithm() string {
+	return algorithmOf(p.PrivateKey.Curve)
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the key ID of the key pair.
+func (p *PublicKey) KeyID() string {
+	return keyID(p.PublicKey)
+}
+
+func algorithmOf(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "ES256"
+	case elliptic.P384():
+		return "ES384"
+	case elliptic.P521():
+		return "ES512"
+	default:
+		return ""
+	}
+}
+
+func keyID(pub *ecdsa.PublicKey) string {
+	kid, _ := thumbprint.Of(pub)
+	return kid
+}
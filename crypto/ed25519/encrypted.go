@@ -0,0 +1,54 @@
+package ed25519
+
+import (
+	"crypto/x509"
+
+	"github.com/golang-devkit/pkg/crypto/pkcs8"
+)
+
+// KeyPairFromEncryptedSecret loads a key pair from its encrypted secret, the output of KeyPair.EncryptedPKCS8PrivateKey.
+func KeyPairFromEncryptedSecret(secret string, passphrase []byte) (*KeyPair, error) {
+	der, err := pkcs8.DecryptBase64(secret, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return keyPairFromPKCS8(der)
+}
+
+// KeyPairFromEncryptedPEM loads a key pair from an "ENCRYPTED PRIVATE KEY" PEM block,
+// e.g. the output of KeyPair.EncryptedPEM or "openssl pkcs8 -topk8 -v2 aes-256-cbc".
+func KeyPairFromEncryptedPEM(privatePEM, passphrase []byte) (*KeyPair, error) {
+	der, err := pkcs8.DecryptPEM(privatePEM, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return keyPairFromPKCS8(der)
+}
+
+// EncryptedPKCS8PrivateKey returns the private key encrypted with the passphrase, like PKCS8PrivateKey:
+// the base64 of the PKCS#8 EncryptedPrivateKeyInfo (PBES2, see package pkcs8).
+func (p *KeyPair) EncryptedPKCS8PrivateKey(passphrase []byte) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return pkcs8.EncryptBase64(der, passphrase)
+}
+
+// EncryptedPEM returns the public key and the private key encrypted with the passphrase,
+// in a PEM block of type "ENCRYPTED PRIVATE KEY".
+func (p *KeyPair) EncryptedPEM(passphrase []byte) (public []byte, private []byte, err error) {
+	public, _, err = p.PEM()
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(p.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	private, err = pkcs8.EncryptPEM(der, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	return public, private, nil
+}
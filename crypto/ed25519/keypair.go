@@ -23,7 +23,10 @@ func KeyPairFromSecret(secret string) (*KeyPair, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode secret: %w", err)
 	}
+	return keyPairFromPKCS8(der)
+}
 
+func keyPairFromPKCS8(der []byte) (*KeyPair, error) {
 	key, err := x509.ParsePKCS8PrivateKey(der)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
@@ -42,6 +45,18 @@ func KeyPairFromSecret(secret string) (*KeyPair, error) {
 	}, nil
 }
 
+func KeyPairFromPEM(privatePEM []byte) (*KeyPair, error) {
+	block, _ := pem.Decode(privatePEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+	if block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	// PKCS8 format
+	return keyPairFromPKCS8(block.Bytes)
+}
+
 type KeyPair struct {
 	PublicKey  ed25519.PublicKey
 	PrivateKey ed25519.PrivateKey
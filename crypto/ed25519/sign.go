@@ -0,0 +1,19 @@
+package ed25519
+
+import (
+	"crypto/ed25519"
+	"fmt"
+)
+
+// SignMessage signs the data with Ed25519, the data is not hashed beforehand (PureEdDSA).
+func (p *KeyPair) SignMessage(data []byte) ([]byte, error) {
+	if len(p.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size: %d", len(p.PrivateKey))
+	}
+	return ed25519.Sign(p.PrivateKey, data), nil
+}
+
+// Verify verifies a signature of SignMessage.
+func (p *KeyPair) Verify(data, signature []byte) error {
+	return verify(p.PublicKey, data, signature)
+}
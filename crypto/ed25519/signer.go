@@ -0,0 +1,47 @@
+package ed25519
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+
+	"github.com/golang-devkit/pkg/crypto/internal/thumbprint"
+)
+
+// Public returns the public key, the key pair is a crypto.Signer.
+func (p *KeyPair) Public() crypto.PublicKey {
+	return p.PublicKey
+}
+
+// Sign signs the message as ed25519.PrivateKey.Sign does (crypto.Signer):
+// the message is not hashed beforehand, unless opts is a *ed25519.Options of Ed25519ph.
+func (p *KeyPair) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if len(p.PrivateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size: %d", len(p.PrivateKey))
+	}
+	if opts == nil {
+		opts = crypto.Hash(0)
+	}
+	return p.PrivateKey.Sign(rand, message, opts)
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the default key ID of the jwt package.
+func (p *KeyPair) KeyID() string {
+	return keyID(p.PublicKey)
+}
+
+// Algorithm returns the JWA name (RFC 7518) of the signatures: EdDSA.
+func (p *KeyPair) Algorithm() string {
+	return "EdDSA"
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the key ID of the key pair.
+func (p *PublicKey) KeyID() string {
+	return keyID(p.PublicKey)
+}
+
+func keyID(pub ed25519.PublicKey) string {
+	kid, _ := thumbprint.Of(pub)
+	return kid
+}
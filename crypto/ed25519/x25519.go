@@ -0,0 +1,64 @@
+package ed25519
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+)
+
+// curve25519P is the prime 2^255 - 19 of Curve25519
+var curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+// X25519 returns the X25519 private key of the key pair, to use the Ed25519 key for key agreement (ECDH).
+// The scalar is derived from the seed as in Ed25519 (RFC 8032, section 5.1.5).
+func (p *KeyPair) X25519() (*ecdh.PrivateKey, error) {
+	return X25519PrivateKey(p.PrivateKey)
+}
+
+// X25519PrivateKey converts an Ed25519 private key to the matching X25519 private key.
+func X25519PrivateKey(key ed25519.PrivateKey) (*ecdh.PrivateKey, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key size: %d", len(key))
+	}
+	h := sha512.Sum512(key.Seed())
+	// The scalar is clamped by X25519
+	return ecdh.X25519().NewPrivateKey(h[:32])
+}
+
+// X25519PublicKey converts an Ed25519 public key to the matching X25519 public key,
+// with the birational map u = (1 + y) / (1 - y) of RFC 7748, section 4.1.
+func X25519PublicKey(pub ed25519.PublicKey) (*ecdh.PublicKey, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key size: %d", len(pub))
+	}
+
+	// y is encoded in little-endian, the most significant bit is the sign of x
+	le := make([]byte, ed25519.PublicKeySize)
+	for i, b := range pub {
+		le[len(pub)-1-i] = b
+	}
+	le[0] &= 0x7f
+	y := new(big.Int).SetBytes(le)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, fmt.Errorf("invalid public key")
+	}
+
+	one := big.NewInt(1)
+	denominator := new(big.Int).Sub(one, y)
+	denominator.Mod(denominator, curve25519P)
+	if denominator.Sign() == 0 {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	u := new(big.Int).Add(one, y)
+	u.Mul(u, denominator.ModInverse(denominator, curve25519P))
+	u.Mod(u, curve25519P)
+
+	// Encode u in little-endian
+	b := u.FillBytes(make([]byte, 32))
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return ecdh.X25519().NewPublicKey(b)
+}
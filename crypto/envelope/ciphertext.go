@@ -0,0 +1,121 @@
+package envelope
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Version of the binary form of Ciphertext, its first byte
+	ciphertextVersion byte = 1
+)
+
+var (
+	errMalformedCiphertext = errors.New("malformed ciphertext")
+)
+
+// Ciphertext is an encrypted blob with everything needed to decrypt it but the master key.
+//
+// Its binary form is:
+//
+//	version (1 byte)
+//	key ID length (1 byte) || key ID
+//	algorithm length (1 byte) || algorithm
+//	wrapped key length (2 bytes, big endian) || wrapped key
+//	nonce length (1 byte) || nonce
+//	data (ciphertext and tag)
+type Ciphertext struct {
+	// KeyId is the ID of the master key which wraps the data key
+	KeyId string
+	// Algorithm encrypts the data with the data key, e.g. AlgorithmA256GCM
+	Algorithm string
+	// WrappedKey is the data key wrapped by the master key
+	WrappedKey []byte
+	Nonce      []byte
+	Data       []byte
+}
+
+// ParseCiphertext parses the binary form of a Ciphertext, e.g. to read the key ID of the master key.
+func ParseCiphertext(data []byte) (*Ciphertext, error) {
+	c := &Ciphertext{}
+	if err := c.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (c *Ciphertext) MarshalBinary() ([]byte, error) {
+	if len(c.KeyId) > 0xff || len(c.Algorithm) > 0xff || len(c.Nonce) > 0xff || len(c.WrappedKey) > 0xffff {
+		return nil, errors.New("ciphertext field is too long")
+	}
+	out := make([]byte, 0, 6+len(c.KeyId)+len(c.Algorithm)+len(c.WrappedKey)+len(c.Nonce)+len(c.Data))
+	out = append(out, ciphertextVersion)
+	out = append(out, byte(len(c.KeyId)))
+	out = append(out, c.KeyId...)
+	out = append(out, byte(len(c.Algorithm)))
+	out = append(out, c.Algorithm...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(c.WrappedKey)))
+	out = append(out, c.WrappedKey...)
+	out = append(out, byte(len(c.Nonce)))
+	out = append(out, c.Nonce...)
+	return append(out, c.Data...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (c *Ciphertext) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return errMalformedCiphertext
+	}
+	if data[0] != ciphertextVersion {
+		return fmt.Errorf("unsupported ciphertext version: %d", data[0])
+	}
+	r := reader(data[1:])
+	keyId, ok1 := r.next(1)
+	alg, ok2 := r.next(1)
+	wrapped, ok3 := r.next(2)
+	nonce, ok4 := r.next(1)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return errMalformedCiphertext
+	}
+	*c = Ciphertext{
+		KeyId:      string(keyId),
+		Algorithm:  string(alg),
+		WrappedKey: wrapped,
+		Nonce:      nonce,
+		Data:       []byte(r),
+	}
+	return nil
+}
+
+// dataAAD returns the additional data of the data encryption: the algorithm and the aad of the caller.
+// The key ID is not authenticated with the data, so a rewrap does not change the data.
+func (c *Ciphertext) dataAAD(aad []byte) []byte {
+	out := make([]byte, 0, 2+len(c.Algorithm)+len(aad))
+	out = append(out, ciphertextVersion, byte(len(c.Algorithm)))
+	out = append(out, c.Algorithm...)
+	return append(out, aad...)
+}
+
+// reader reads the length-prefixed fields of the binary form.
+type reader []byte
+
+// next returns the next field, its length is encoded on size bytes.
+func (r *reader) next(size int) ([]byte, bool) {
+	if len(*r) < size {
+		return nil, false
+	}
+	var n int
+	if size == 1 {
+		n = int((*r)[0])
+	} else {
+		n = int(binary.BigEndian.Uint16(*r))
+	}
+	if len(*r) < size+n {
+		return nil, false
+	}
+	field := (*r)[size : size+n]
+	*r = (*r)[size+n:]
+	return field, true
+}
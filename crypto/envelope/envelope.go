@@ -0,0 +1,179 @@
+// Package envelope encrypts blobs with envelope encryption, e.g. the secrets stored in MongoDB.
+//
+// Each blob is encrypted with a fresh data key under AES-256-GCM, the data key is wrapped
+// by a master key of a KMS and stored next to the data in a self-describing Ciphertext.
+// The master keys never leave the KMS: Keyfile is the local implementation, a cloud KMS implements the same interface.
+//
+// On master-key rotation, Keyring.Rewrap wraps the data key with the new master key
+// without re-encrypting the data, so the old master key can be retired once every blob is rewrapped.
+//
+// Example:
+//
+//	kms, err := envelope.OpenKeyfile("/etc/app/master-keys.json")
+//	// ...
+//	keyring := envelope.NewKeyring(kms)
+//	ciphertext, err := keyring.Encrypt(ctx, []byte(apiSecret), []byte(clientId))
+//	// ...
+//	apiSecret, err := keyring.Decrypt(ctx, ciphertext, []byte(clientId))
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+const (
+	// AlgorithmA256GCM is the JWA name (RFC 7518) of AES-256-GCM, the algorithm of the data
+	AlgorithmA256GCM = "A256GCM"
+
+	dataKeySize = 32
+)
+
+var (
+	// ErrDecrypt is returned when a ciphertext cannot be decrypted: it is tampered, or the aad does not match.
+	ErrDecrypt = errors.New("envelope: message authentication failed")
+)
+
+// KMS wraps the data keys with its master keys, identified by key ID.
+type KMS interface {
+	// Wrap encrypts the data key with the active master key, and returns the ID of that master key.
+	Wrap(ctx context.Context, dataKey []byte) (keyId string, wrapped []byte, err error)
+	// Unwrap decrypts the data key wrapped by the master key with the key ID.
+	Unwrap(ctx context.Context, keyId string, wrapped []byte) ([]byte, error)
+	// ActiveKeyId returns the ID of the master key used by Wrap.
+	ActiveKeyId(ctx context.Context) (string, error)
+}
+
+// Keyring encrypts and decrypts blobs with data keys wrapped by the KMS.
+type Keyring struct {
+	kms KMS
+}
+
+// NewKeyring creates a Keyring with the master keys of the KMS.
+func NewKeyring(kms KMS) *Keyring {
+	return &Keyring{kms: kms}
+}
+
+// Encrypt encrypts the plaintext with a fresh data key and returns the binary form of the Ciphertext.
+// The additional data (aad) is authenticated but not stored, the same aad is required by Decrypt,
+// e.g. the ID of the document the secret belongs to, so the ciphertext cannot be moved to another document.
+func (k *Keyring) Encrypt(ctx context.Context, plaintext, aad []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	keyId, wrapped, err := k.kms.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	c := &Ciphertext{
+		KeyId:      keyId,
+		Algorithm:  AlgorithmA256GCM,
+		WrappedKey: wrapped,
+		Nonce:      make([]byte, 12),
+	}
+	if _, err := rand.Read(c.Nonce); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	c.Data = gcm.Seal(nil, c.Nonce, plaintext, c.dataAAD(aad))
+	return c.MarshalBinary()
+}
+
+// Decrypt decrypts the binary form of a Ciphertext with the aad given to Encrypt.
+func (k *Keyring) Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	c, err := ParseCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if c.Algorithm != AlgorithmA256GCM {
+		return nil, fmt.Errorf("unsupported algorithm: %s", c.Algorithm)
+	}
+	dataKey, err := k.unwrap(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Nonce) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := gcm.Open(nil, c.Nonce, c.Data, c.dataAAD(aad))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Rewrap wraps the data key of the ciphertext with the active master key of the KMS, the data is not re-encrypted.
+// The ciphertext is returned unchanged when it is already wrapped by the active master key.
+//
+// Example, after a rotation of the master key:
+//
+//	for _, doc := range docs {
+//		if doc.Secret, err = keyring.Rewrap(ctx, doc.Secret); err != nil {
+//			// ...
+//		}
+//		// update the document
+//	}
+func (k *Keyring) Rewrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	c, err := ParseCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	// The data key is not unwrapped when there is nothing to rewrap, a rewrap pass over
+	// the blobs costs no KMS call for the blobs already rewrapped
+	activeKeyId, err := k.kms.ActiveKeyId(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the active master key: %w", err)
+	}
+	if c.KeyId == activeKeyId {
+		return ciphertext, nil
+	}
+	dataKey, err := k.unwrap(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	keyId, wrapped, err := k.kms.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	c.KeyId, c.WrappedKey = keyId, wrapped
+	return c.MarshalBinary()
+}
+
+// unwrap unwraps the data key of the ciphertext, it must be an AES-256 key.
+func (k *Keyring) unwrap(ctx context.Context, c *Ciphertext) ([]byte, error) {
+	dataKey, err := k.kms.Unwrap(ctx, c.KeyId, c.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if len(dataKey) != dataKeySize {
+		clear(dataKey)
+		return nil, fmt.Errorf("failed to unwrap data key: invalid size %d", len(dataKey))
+	}
+	return dataKey, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,160 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "master-keys.json")
+	kms, err := OpenKeyfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring := NewKeyring(kms)
+	plaintext, aad := []byte("api secret"), []byte("client-1")
+
+	if _, err := keyring.Encrypt(ctx, plaintext, aad); !errors.Is(err, ErrNoActiveKey) {
+		t.Fatalf("expected ErrNoActiveKey, got %v", err)
+	}
+	first, err := kms.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("unexpected keyfile mode: %v %v", info, err)
+	}
+
+	ciphertext, err := keyring.Encrypt(ctx, plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseCiphertext(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.KeyId != first || c.Algorithm != AlgorithmA256GCM {
+		t.Fatalf("unexpected ciphertext header: %s %s", c.KeyId, c.Algorithm)
+	}
+	got, err := keyring.Decrypt(ctx, ciphertext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("unexpected plaintext %q", got)
+	}
+	if _, err := keyring.Decrypt(ctx, ciphertext, []byte("client-2")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt for the wrong aad, got %v", err)
+	}
+	tampered := bytes.Clone(ciphertext)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := keyring.Decrypt(ctx, tampered, aad); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt for a tampered ciphertext, got %v", err)
+	}
+	if _, err := ParseCiphertext(ciphertext[:10]); err == nil {
+		t.Fatal("expected an error for a truncated ciphertext")
+	}
+
+	// Rotate, reopen the file and rewrap: the data is unchanged
+	second, err := kms.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kms, err = OpenKeyfile(path); err != nil {
+		t.Fatal(err)
+	}
+	if active, err := kms.ActiveKeyId(ctx); err != nil || active != second {
+		t.Fatalf("unexpected active key %s: %v", active, err)
+	}
+	keyring = NewKeyring(kms)
+	rewrapped, err := keyring.Rewrap(ctx, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ParseCiphertext(rewrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.KeyId != second || !bytes.Equal(r.Data, c.Data) || !bytes.Equal(r.Nonce, c.Nonce) {
+		t.Fatal("rewrap should only change the wrapped key")
+	}
+	if again, err := keyring.Rewrap(ctx, rewrapped); err != nil || !bytes.Equal(again, rewrapped) {
+		t.Fatalf("rewrap with the active key should be a no-op: %v", err)
+	}
+
+	// The retired key can be removed once every data key is rewrapped
+	if err := kms.Remove(ctx, second); err == nil {
+		t.Fatal("expected an error when removing the active key")
+	}
+	if err := kms.Remove(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.Decrypt(ctx, ciphertext, aad); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+	if got, err = keyring.Decrypt(ctx, rewrapped, aad); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("failed to decrypt the rewrapped ciphertext: %v", err)
+	}
+}
+
+// countingKMS counts the data keys unwrapped, and can truncate them.
+type countingKMS struct {
+	*Keyfile
+	unwraps  int
+	truncate bool
+}
+
+func (k *countingKMS) Unwrap(ctx context.Context, keyId string, wrapped []byte) ([]byte, error) {
+	k.unwraps++
+	dataKey, err := k.Keyfile.Unwrap(ctx, keyId, wrapped)
+	if err != nil || !k.truncate {
+		return dataKey, err
+	}
+	return dataKey[:16], nil
+}
+
+func TestKeyringRewrapActiveKey(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	keyfile, err := OpenKeyfile(filepath.Join(t.TempDir(), "master-keys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyfile.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	kms := &countingKMS{Keyfile: keyfile}
+	keyring := NewKeyring(kms)
+	ciphertext, err := keyring.Encrypt(ctx, []byte("api secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The data key is not unwrapped for a ciphertext of the active key
+	if rewrapped, err := keyring.Rewrap(ctx, ciphertext); err != nil || !bytes.Equal(rewrapped, ciphertext) {
+		t.Fatalf("rewrap with the active key should be a no-op: %v", err)
+	}
+	if kms.unwraps != 0 {
+		t.Fatalf("expected no unwrap, got %d", kms.unwraps)
+	}
+
+	// A data key of another size is rejected
+	if _, err := keyfile.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	kms.truncate = true
+	if _, err := keyring.Rewrap(ctx, ciphertext); err == nil {
+		t.Fatal("expected an error for a truncated data key")
+	}
+	if _, err := keyring.Decrypt(ctx, ciphertext, nil); err == nil {
+		t.Fatal("expected an error for a truncated data key")
+	}
+}
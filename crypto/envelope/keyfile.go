@@ -0,0 +1,220 @@
+package envelope
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrKeyNotFound is returned when no master key has the key ID.
+	ErrKeyNotFound = errors.New("master key not found")
+	// ErrNoActiveKey is returned by Keyfile.Wrap when no master key has been created yet.
+	ErrNoActiveKey = errors.New("no active master key")
+)
+
+// keyfileContent is the content of the keyfile.
+type keyfileContent struct {
+	Keys []masterKey `json:"keys"`
+}
+
+type masterKey struct {
+	ID        string    `json:"id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"createdAt"`
+	Active    bool      `json:"active,omitempty"`
+}
+
+// Keyfile is a KMS of master keys stored in a local JSON file, the local stand-in of a cloud KMS.
+// The master keys are AES-256 keys, they wrap the data keys with AES-256-GCM.
+//
+// The file is written with mode 0600 and contains the keys in base64:
+//
+//	{
+//		"keys": [
+//			{
+//				"id": "0cf835de-5c39-481d-a371-94884ba91fcd",
+//				"key": "q6m0Wc9cmpC1QzH0CyA5e6tYqbY6Oe6dT5mA2cK9Xt8=",
+//				"createdAt": "2026-01-02T15:04:05Z",
+//				"active": true
+//			}
+//		]
+//	}
+//
+// The keys are read once by OpenKeyfile. It is safe for concurrent use in a process,
+// a single process should rotate the keys.
+type Keyfile struct {
+	path string
+
+	mu   sync.RWMutex
+	keys []masterKey
+}
+
+var _ KMS = (*Keyfile)(nil)
+
+// OpenKeyfile reads the master keys of the file.
+// The file does not need to exist, it is created by the first Rotate.
+func OpenKeyfile(path string) (*Keyfile, error) {
+	kf := &Keyfile{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return kf, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyfile: %w", err)
+	}
+	var content keyfileContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse keyfile: %w", err)
+	}
+	for _, key := range content.Keys {
+		if len(key.Key) != dataKeySize {
+			return nil, fmt.Errorf("invalid size of master key %s: %d", key.ID, len(key.Key))
+		}
+	}
+	kf.keys = content.Keys
+	return kf, nil
+}
+
+// ActiveKeyId returns the ID of the master key which wraps the new data keys,
+// or ErrNoActiveKey when no master key has been created yet.
+func (kf *Keyfile) ActiveKeyId(ctx context.Context) (string, error) {
+	kf.mu.RLock()
+	defer kf.mu.RUnlock()
+
+	if key, ok := kf.active(); ok {
+		return key.ID, nil
+	}
+	return "", ErrNoActiveKey
+}
+
+// Rotate creates a master key and makes it the active key, then writes the file.
+// The previous keys stay in the file to unwrap the data keys until they are rewrapped (see Keyring.Rewrap).
+func (kf *Keyfile) Rotate(ctx context.Context) (string, error) {
+	key := masterKey{
+		ID:        uuid.NewString(),
+		Key:       make([]byte, dataKeySize),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Active:    true,
+	}
+	if _, err := rand.Read(key.Key); err != nil {
+		return "", err
+	}
+
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+
+	keys := slices.Clone(kf.keys)
+	for i := range keys {
+		keys[i].Active = false
+	}
+	keys = append(keys, key)
+	if err := kf.write(keys); err != nil {
+		return "", err
+	}
+	kf.keys = keys
+	return key.ID, nil
+}
+
+// Remove removes a retired master key from the file, once no data key is wrapped by it anymore.
+func (kf *Keyfile) Remove(ctx context.Context, keyId string) error {
+	kf.mu.Lock()
+	defer kf.mu.Unlock()
+
+	i := slices.IndexFunc(kf.keys, func(key masterKey) bool { return key.ID == keyId })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, keyId)
+	}
+	if kf.keys[i].Active {
+		return fmt.Errorf("cannot remove the active master key %s", keyId)
+	}
+	keys := slices.Delete(slices.Clone(kf.keys), i, i+1)
+	if err := kf.write(keys); err != nil {
+		return err
+	}
+	kf.keys = keys
+	return nil
+}
+
+func (kf *Keyfile) Wrap(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	kf.mu.RLock()
+	defer kf.mu.RUnlock()
+
+	key, ok := kf.active()
+	if !ok {
+		return "", nil, ErrNoActiveKey
+	}
+	gcm, err := newGCM(key.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	// The key ID is authenticated, a wrapped key cannot be attributed to another master key
+	return key.ID, gcm.Seal(nonce, nonce, dataKey, []byte(key.ID)), nil
+}
+
+func (kf *Keyfile) Unwrap(ctx context.Context, keyId string, wrapped []byte) ([]byte, error) {
+	kf.mu.RLock()
+	defer kf.mu.RUnlock()
+
+	i := slices.IndexFunc(kf.keys, func(key masterKey) bool { return key.ID == keyId })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyId)
+	}
+	gcm, err := newGCM(kf.keys[i].Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	dataKey, err := gcm.Open(nil, wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():], []byte(keyId))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return dataKey, nil
+}
+
+func (kf *Keyfile) active() (masterKey, bool) {
+	i := slices.IndexFunc(kf.keys, func(key masterKey) bool { return key.Active })
+	if i < 0 {
+		return masterKey{}, false
+	}
+	return kf.keys[i], true
+}
+
+// write replaces the file atomically, through a temporary file of mode 0600.
+func (kf *Keyfile) write(keys []masterKey) error {
+	data, err := json.MarshalIndent(keyfileContent{Keys: keys}, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(kf.path), filepath.Base(kf.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write keyfile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write keyfile: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write keyfile: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), kf.path); err != nil {
+		return fmt.Errorf("failed to write keyfile: %w", err)
+	}
+	return nil
+}
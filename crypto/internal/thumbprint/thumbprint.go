@@ -0,0 +1,90 @@
+// Package thumbprint computes the RFC 7638 thumbprints of the public keys,
+// the key IDs of the key packages and of the jwt package.
+package thumbprint
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+const (
+	KeyTypeEC  = "EC"
+	KeyTypeRSA = "RSA"
+	KeyTypeOKP = "OKP"
+
+	CurveEd25519 = "Ed25519"
+)
+
+// Key holds the required members of a JSON Web Key (RFC 7517), base64url encoded.
+type Key struct {
+	Kty string
+	// EC and OKP
+	Crv string
+	X   string
+	Y   string
+	// RSA
+	E string
+	N string
+}
+
+// PublicKey returns the members of an ed25519, ecdsa or rsa public key.
+func PublicKey(pub crypto.PublicKey) (Key, error) {
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		return Key{Kty: KeyTypeOKP, Crv: CurveEd25519, X: base64.RawURLEncoding.EncodeToString(k)}, nil
+	case *ecdsa.PublicKey:
+		b, err := k.Bytes()
+		if err != nil {
+			return Key{}, fmt.Errorf("failed to encode ecdsa public key: %w", err)
+		}
+		// Uncompressed point: 0x04 || X || Y
+		size := (len(b) - 1) / 2
+		return Key{
+			Kty: KeyTypeEC,
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(b[1 : 1+size]),
+			Y:   base64.RawURLEncoding.EncodeToString(b[1+size:]),
+		}, nil
+	case *rsa.PublicKey:
+		return Key{
+			Kty: KeyTypeRSA,
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+		}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+}
+
+// Sum computes the RFC 7638 thumbprint (SHA-256, base64url) of the key.
+func (k Key) Sum() (string, error) {
+	var canonical string
+	// Required members only, in lexicographic order
+	switch k.Kty {
+	case KeyTypeEC:
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, k.Crv, k.Kty, k.X, k.Y)
+	case KeyTypeOKP:
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s"}`, k.Crv, k.Kty, k.X)
+	case KeyTypeRSA:
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"%s","n":"%s"}`, k.E, k.Kty, k.N)
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// Of computes the RFC 7638 thumbprint of an ed25519, ecdsa or rsa public key.
+func Of(pub crypto.PublicKey) (string, error) {
+	key, err := PublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return key.Sum()
+}
@@ -0,0 +1,46 @@
+package thumbprint
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+
+	// RFC 8037, appendix A.3
+	x, err := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid, err := Of(ed25519.PublicKey(x)); err != nil || kid != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Fatalf("unexpected thumbprint %q, %v", kid, err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pub := range []any{&ecKey.PublicKey, &rsaKey.PublicKey} {
+		kid, err := Of(pub)
+		if err != nil || len(kid) != 43 {
+			t.Fatalf("%T: unexpected thumbprint %q, %v", pub, kid, err)
+		}
+	}
+
+	if _, err := Of("not a key"); err == nil {
+		t.Fatal("expected an error for an unsupported key")
+	}
+	if _, err := (Key{Kty: "oct"}).Sum(); err == nil {
+		t.Fatal("expected an error for an unsupported key type")
+	}
+}
@@ -0,0 +1,138 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Claims is the typed counterpart of MapClaims: the payload is decoded straight into T,
+// without the json.Marshal round trip of MapClaims.ParsePayload.
+//
+// The payload is encoded with protojson when T is a proto.Message (e.g. *pb.User),
+// with encoding/json otherwise. MapClaims.Payload is always nil, use Claims.Payload.
+//
+// Example:
+//
+//	str, err := jwt.Sign(keyPair, &pb.User{Id: userId}, jwt.NewOption().SetUserId(userId))
+//
+//	claims, err := jwt.Parse[*pb.User](keyPair.PublicKey, str)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(claims.Payload.GetId())
+type Claims[T any] struct {
+	MapClaims
+	Payload T `json:"-"`
+}
+
+func (claims *MapClaims) mapClaims() *MapClaims {
+	return claims
+}
+
+// MarshalJSON encodes the claims, the payload is encoded under the "payload" key.
+func (claims Claims[T]) MarshalJSON() ([]byte, error) {
+	raw, err := encodePayload(claims.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	base := claims.MapClaims
+	base.Payload = nil
+	if raw != nil {
+		base.Payload = raw
+	}
+	return json.Marshal(&base)
+}
+
+// UnmarshalJSON decodes the claims, the "payload" key is decoded into Claims.Payload.
+func (claims *Claims[T]) UnmarshalJSON(b []byte) error {
+	var raw json.RawMessage
+	base := MapClaims{Payload: &raw}
+	if err := json.Unmarshal(b, &base); err != nil {
+		return err
+	}
+	base.Payload = nil
+	claims.MapClaims = base
+
+	var zero T
+	claims.Payload = zero
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := decodePayload(raw, &claims.Payload); err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+	return nil
+}
+
+// encodePayload returns nil for a nil payload, so it is omitted.
+func encodePayload[T any](v T) (json.RawMessage, error) {
+	rv := reflect.ValueOf(&v).Elem()
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	if msg, ok := any(v).(proto.Message); ok {
+		return protojson.Marshal(msg)
+	}
+	return json.Marshal(v)
+}
+
+func decodePayload[T any](raw json.RawMessage, v *T) error {
+	// T is a pointer to a message (e.g. *pb.User): allocate the message before decoding
+	if _, ok := any(*v).(proto.Message); ok {
+		t := reflect.TypeOf(*v)
+		if t.Kind() != reflect.Pointer {
+			return fmt.Errorf("unsupported message type %s", t)
+		}
+		msg := reflect.New(t.Elem()).Interface().(proto.Message)
+		if err := protojson.Unmarshal(raw, msg); err != nil {
+			return err
+		}
+		*v = msg.(T)
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Sign signs the typed payload with the given private key, see SignWithClaims.
+// A proto.Message payload is encoded with protojson.
+func Sign[T any](key interface{}, payload T, opts ...*Option) (string, error) {
+
+	opt := mergeOption(opts)
+
+	claims := Claims[T]{
+		MapClaims: newClaims(opt),
+		Payload:   payload,
+	}
+
+	return signClaims(key, &claims, opt)
+}
+
+// Parse parses and validates the token like ParseClaims, and decodes its payload into T.
+func Parse[T any](pub crypto.PublicKey, str string, opts ...*ParseOption) (*Claims[T], error) {
+
+	opt := mergeParseOption(opts)
+	key, algorithms, err := verificationKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if algorithms, err = opt.allowedAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims[T]{}
+	if err := parseClaimsInto(context.Background(), "Parse", str, claims, keyfunc(key, algorithms), opt,
+		jwt.WithValidMethods(algorithms)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
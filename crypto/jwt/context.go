@@ -6,10 +6,26 @@ type contextKey string
 
 const (
 	// ClaimsKey is the key used to store JWT claims in the context
+	ClaimsKey    contextKey = "claims"
 	SessionIdKey contextKey = "sessionIdOfClaims"
 	UserIdKey    contextKey = "userIdOfClaims"
+	TenantIdKey  contextKey = "tenantIdOfClaims"
+	RolesKey     contextKey = "rolesOfClaims"
+	ScopesKey    contextKey = "scopesOfClaims"
 )
 
+func setClaimsToContext(ctx context.Context, claims *MapClaims) context.Context {
+	return context.WithValue(ctx, ClaimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims applied by MapClaims.ApplyContext, or nil.
+func ClaimsFromContext(ctx context.Context) *MapClaims {
+	if val, ok := ctx.Value(ClaimsKey).(*MapClaims); ok {
+		return val
+	}
+	return nil
+}
+
 func setSessionIdToContext(ctx context.Context, sessionId string) context.Context {
 	return context.WithValue(ctx, SessionIdKey, sessionId)
 }
@@ -31,3 +47,36 @@ func UserIdFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+func setTenantIdToContext(ctx context.Context, tenantId string) context.Context {
+	return context.WithValue(ctx, TenantIdKey, tenantId)
+}
+
+func TenantIdFromContext(ctx context.Context) string {
+	if val, ok := ctx.Value(TenantIdKey).(string); ok {
+		return val
+	}
+	return ""
+}
+
+func setRolesToContext(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, RolesKey, roles)
+}
+
+func RolesFromContext(ctx context.Context) []string {
+	if val, ok := ctx.Value(RolesKey).([]string); ok {
+		return val
+	}
+	return nil
+}
+
+func setScopesToContext(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, ScopesKey, scopes)
+}
+
+func ScopesFromContext(ctx context.Context) []string {
+	if val, ok := ctx.Value(ScopesKey).([]string); ok {
+		return val
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package jwt
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// dpopType is the "typ" header of a DPoP proof (RFC 9449, section 4.2)
+	dpopType = "dpop+jwt"
+	// headerJWK is the header holding the public key of a DPoP proof
+	headerJWK = "jwk"
+)
+
+var (
+	// ErrDPoPProofInvalid is returned when the DPoP proof is malformed, badly signed or does not match the request.
+	ErrDPoPProofInvalid = errors.New("invalid DPoP proof")
+	// ErrDPoPProofReplayed is returned when the "jti" of the DPoP proof has already been used.
+	ErrDPoPProofReplayed = errors.New("DPoP proof has already been used")
+	// ErrDPoPKeyMismatch is returned when the DPoP proof is not signed by the key bound to the token ("cnf.jkt").
+	ErrDPoPKeyMismatch = errors.New("DPoP proof key does not match the token")
+)
+
+// Confirmation is the "cnf" claim (RFC 7800) binding the token to a key.
+type Confirmation struct {
+	// JKT is the RFC 7638 thumbprint of the DPoP key (RFC 9449, section 6.1)
+	JKT string `json:"jkt,omitempty"`
+}
+
+// confirmation returns the "cnf" claim of the thumbprint, or nil.
+func confirmation(jkt string) *Confirmation {
+	if jkt == "" {
+		return nil
+	}
+	return &Confirmation{JKT: jkt}
+}
+
+// dpopClaims are the claims of a DPoP proof.
+type dpopClaims struct {
+	jwt.RegisteredClaims
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+}
+
+// NewDPoPProof creates the DPoP proof (RFC 9449) of a HTTP request, to send in the "DPoP" header.
+// The key is an Ed25519 or ECDSA private key (any key accepted by SignWithClaims),
+// its public key is embedded in the proof.
+//
+// The accessToken is the token sent with the request, its hash is bound to the proof ("ath" claim).
+// It is empty when the proof is sent to the token endpoint.
+//
+// Example:
+//
+//	proof, err := jwt.NewDPoPProof(keyPair, http.MethodGet, "https://api.example.com/accounts/1", accessToken)
+//	req.Header.Set("Authorization", "DPoP "+accessToken)
+//	req.Header.Set("DPoP", proof)
+func NewDPoPProof(key any, method, uri, accessToken string) (string, error) {
+	htu, err := normalizeHTU(uri)
+	if err != nil {
+		return "", err
+	}
+	sign, signKey, err := signingMethod(key, "")
+	if err != nil {
+		return "", err
+	}
+	jwk, err := NewJSONWebKey(signKey)
+	if err != nil {
+		return "", err
+	}
+	// Only the members of the public key
+	jwk.Kid, jwk.Use, jwk.Alg = "", "", ""
+
+	claims := dpopClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       uuid.NewString(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+		HTM: strings.ToUpper(method),
+		HTU: htu,
+	}
+	if accessToken != "" {
+		claims.ATH = accessTokenHash(accessToken)
+	}
+
+	token := jwt.NewWithClaims(sign, &claims)
+	token.Header["typ"] = dpopType
+	token.Header[headerJWK] = jwk
+	return token.SignedString(signKey)
+}
+
+// DPoPThumbprint returns the thumbprint of the DPoP key, to bind a token to the key with Option.SetDPoPThumbprint.
+func DPoPThumbprint(key any) (string, error) {
+	return KeyId(key)
+}
+
+// VerifyDPoPBinding checks the token is bound to the key of the DPoP proof ("cnf.jkt" claim).
+func (claims *MapClaims) VerifyDPoPBinding(jkt string) error {
+	if claims.Confirmation == nil || claims.Confirmation.JKT == "" {
+		return fmt.Errorf("%w: token is not bound to a DPoP key", ErrDPoPKeyMismatch)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Confirmation.JKT), []byte(jkt)) != 1 {
+		return ErrDPoPKeyMismatch
+	}
+	return nil
+}
+
+// DPoPRequest is the request a DPoP proof is verified against.
+type DPoPRequest struct {
+	Method string
+	URL    string
+	// AccessToken is the token sent with the proof, empty at the token endpoint.
+	AccessToken string
+}
+
+// ReplayCache remembers the DPoP proofs already used.
+type ReplayCache interface {
+	// Seen records the proof ID until expiresAt, and reports whether it was already recorded.
+	// It must be atomic.
+	Seen(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+}
+
+// DPoPVerifier verifies the DPoP proofs (RFC 9449, section 4.3).
+//
+// Example:
+//
+//	dpop := jwt.NewDPoPVerifier()
+//	// at the token endpoint
+//	jkt, err := dpop.Verify(ctx, r.Header.Get("DPoP"), jwt.DPoPRequest{Method: r.Method, URL: tokenURL})
+//	str, err := jwt.SignWithClaims(keyPair, payload, jwt.NewOption().SetDPoPThumbprint(jkt))
+type DPoPVerifier struct {
+	maxAge time.Duration
+	leeway time.Duration
+	replay ReplayCache
+}
+
+// NewDPoPVerifier creates a DPoPVerifier accepting the proofs issued in the last minute,
+// with an in-memory replay cache.
+func NewDPoPVerifier() *DPoPVerifier {
+	return &DPoPVerifier{
+		maxAge: time.Minute,
+		leeway: 5 * time.Second,
+		replay: NewMemoryReplayCache(),
+	}
+}
+
+// SetMaxAge sets how long after its "iat" a proof is accepted.
+func (src *DPoPVerifier) SetMaxAge(d time.Duration) *DPoPVerifier {
+	dst := *src
+	dst.maxAge = d
+	return &dst
+}
+
+func (src *DPoPVerifier) MaxAge() time.Duration {
+	return src.maxAge
+}
+
+// SetLeeway sets the clock skew tolerated on the "iat" of the proofs.
+func (src *DPoPVerifier) SetLeeway(d time.Duration) *DPoPVerifier {
+	dst := *src
+	dst.leeway = d
+	return &dst
+}
+
+func (src *DPoPVerifier) Leeway() time.Duration {
+	return src.leeway
+}
+
+// SetReplayCache replaces the in-memory replay cache, e.g. by a shared cache for several instances.
+func (src *DPoPVerifier) SetReplayCache(cache ReplayCache) *DPoPVerifier {
+	dst := *src
+	dst.replay = cache
+	return &dst
+}
+
+// Verify verifies the DPoP proof against the request and returns the thumbprint of its key.
+// The proof must be signed by its embedded key, be recent, match the method and URL of the request,
+// be bound to the access token if any, and be used once.
+func (v *DPoPVerifier) Verify(ctx context.Context, proof string, req DPoPRequest) (string, error) {
+	if proof == "" {
+		return "", fmt.Errorf("%w: missing proof", ErrDPoPProofInvalid)
+	}
+	var jwk JSONWebKey
+	claims := &dpopClaims{}
+	_, err := jwt.ParseWithClaims(proof, claims, func(t *jwt.Token) (interface{}, error) {
+		if typ, _ := t.Header["typ"].(string); !strings.EqualFold(typ, dpopType) {
+			return nil, fmt.Errorf("unexpected type %q", typ)
+		}
+		raw, err := json.Marshal(t.Header[headerJWK])
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &jwk); err != nil {
+			return nil, fmt.Errorf("invalid jwk header: %w", err)
+		}
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		_, algorithms, err := verificationKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return keyfunc(pub, algorithms)(t)
+	},
+		jwt.WithValidMethods(slices.Concat([]string{AlgEdDSA, AlgES256, AlgES384, AlgES512}, algorithmsRSA)),
+		jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDPoPProofInvalid, err)
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return "", fmt.Errorf("%w: missing jti or iat", ErrDPoPProofInvalid)
+	}
+	now := time.Now()
+	if claims.IssuedAt.After(now.Add(v.leeway)) || claims.IssuedAt.Add(v.maxAge+v.leeway).Before(now) {
+		return "", fmt.Errorf("%w: iat is out of the acceptable window", ErrDPoPProofInvalid)
+	}
+	if claims.HTM != req.Method {
+		return "", fmt.Errorf("%w: htm does not match the request method", ErrDPoPProofInvalid)
+	}
+	htu, err := normalizeHTU(claims.HTU)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDPoPProofInvalid, err)
+	}
+	reqURL, err := normalizeHTU(req.URL)
+	if err != nil {
+		return "", err
+	}
+	if htu != reqURL {
+		return "", fmt.Errorf("%w: htu does not match the request URL", ErrDPoPProofInvalid)
+	}
+	if req.AccessToken != "" &&
+		subtle.ConstantTimeCompare([]byte(claims.ATH), []byte(accessTokenHash(req.AccessToken))) != 1 {
+		return "", fmt.Errorf("%w: ath does not match the access token", ErrDPoPProofInvalid)
+	}
+
+	// The proof is checked for replay last, so an invalid proof does not consume its jti
+	if v.replay != nil {
+		seen, err := v.replay.Seen(ctx, claims.ID, claims.IssuedAt.Add(v.maxAge+v.leeway))
+		if err != nil {
+			return "", err
+		}
+		if seen {
+			return "", ErrDPoPProofReplayed
+		}
+	}
+	return jwk.Thumbprint()
+}
+
+// normalizeHTU returns the URL without query and fragment, with lowercase scheme and host (RFC 9449, section 4.3).
+func normalizeHTU(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q: absolute URL is required", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery, u.Fragment, u.RawFragment = "", "", ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String(), nil
+}
+
+func accessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// MemoryReplayCache is an in-memory ReplayCache, for a single instance or for tests.
+type MemoryReplayCache struct {
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	swept time.Time
+}
+
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{
+		seen:  make(map[string]time.Time),
+		swept: time.Now(),
+	}
+}
+
+func (c *MemoryReplayCache) Seen(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// Drop the expired entries, at most once per minute
+	if now.Sub(c.swept) >= time.Minute {
+		c.swept = now
+		for key, exp := range c.seen {
+			if !now.Before(exp) {
+				delete(c.seen, key)
+			}
+		}
+	}
+	if exp, ok := c.seen[jti]; ok && now.Before(exp) {
+		return true, nil
+	}
+	c.seen[jti] = expiresAt
+	return false, nil
+}
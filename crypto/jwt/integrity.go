@@ -0,0 +1,57 @@
+package jwt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrProtobufDataHashMismatch is returned when the request does not match the hash signed in the token.
+	ErrProtobufDataHashMismatch = errors.New("protobuf data hash does not match the token")
+)
+
+// ProtobufDataHash returns the hex encoded SHA-256 of the full gRPC method (e.g. "/payment.v1.PaymentService/Transfer")
+// and of the deterministic wire encoding of the message, separated by a newline.
+// It is the value signed in the "protobufDataHash" claim to bind a token to a gRPC request:
+// the token can not be replayed with another method taking the same message.
+func ProtobufDataHash(fullMethod string, msg proto.Message) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal protobuf message: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(fullMethod + "\n"))
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SignWithProtoMessage signs a token bound to the given request of the full gRPC method,
+// the hash of the method and the message is set in the "protobufDataHash" claim.
+//
+// Example:
+//
+//	req := &pb.TransferRequest{From: "...", To: "...", Amount: 100}
+//	str, err := jwt.SignWithProtoMessage(keyPair, "/payment.v1.PaymentService/Transfer", req, nil, jwt.NewOption().SetUserId(userId))
+func SignWithProtoMessage(key interface{}, fullMethod string, msg proto.Message, payload any, opts ...*Option) (string, error) {
+	hash, err := ProtobufDataHash(fullMethod, msg)
+	if err != nil {
+		return "", err
+	}
+	return SignWithClaims(key, payload, mergeOption(opts).SetProtobufDataHash(hash))
+}
+
+// VerifyProtobufDataHash compares the hash of the request with the "protobufDataHash" claim in constant time.
+func (claims *MapClaims) VerifyProtobufDataHash(hash string) error {
+	if claims.ProtobufDataHash == "" {
+		return fmt.Errorf("%w: token is not bound to a request", ErrProtobufDataHashMismatch)
+	}
+	if hash == "" || subtle.ConstantTimeCompare([]byte(claims.ProtobufDataHash), []byte(hash)) != 1 {
+		return ErrProtobufDataHashMismatch
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+// Algorithm names of the encrypted tokens (JWE, RFC 7516).
+const (
+	// AlgECDHES is the ECDH-ES key agreement with X25519, the agreed key is the content encryption key
+	AlgECDHES = "ECDH-ES"
+	// AlgRSAOAEP256 wraps a random content encryption key with RSA-OAEP and SHA-256
+	AlgRSAOAEP256 = "RSA-OAEP-256"
+	// EncA256GCM is the content encryption with AES-256-GCM
+	EncA256GCM = "A256GCM"
+
+	curveX25519 = "X25519"
+
+	// contentTypeJWT is the "cty" header of a nested token (RFC 7519, section 5.2)
+	contentTypeJWT = "JWT"
+
+	cekSize = 32
+)
+
+var (
+	// ErrDecryption is returned when the token can not be decrypted with the given key.
+	// The cause is not detailed on purpose.
+	ErrDecryption = errors.New("failed to decrypt token")
+)
+
+type jweHeader struct {
+	Alg  string      `json:"alg"`
+	Enc  string      `json:"enc"`
+	Cty  string      `json:"cty,omitempty"`
+	Epk  *JSONWebKey `json:"epk,omitempty"`
+	Zip  string      `json:"zip,omitempty"`
+	Crit []string    `json:"crit,omitempty"`
+}
+
+// EncryptWithClaims signs the payload like SignWithClaims, then encrypts the signed token for the recipient (nested JWT).
+// Only the holder of the recipient private key can read the claims.
+//
+// Supported recipient keys:
+//   - *ecdh.PublicKey (X25519), ed25519.PublicKey, *ed25519.PublicKey, *ed25519.KeyPair: ECDH-ES, the Ed25519 key is converted to X25519
+//   - *rsa.PublicKey (of crypto/rsa or of this module), *rsa.KeyPair: RSA-OAEP-256
+//
+// The content is encrypted with A256GCM.
+//
+// Example:
+//
+//	str, err := jwt.EncryptWithClaims(issuerKeyPair, serviceKeyPair.PublicKey, payload, jwt.NewOption().SetUserId(userId))
+//	// ... on the service
+//	claims, err := jwt.ParseEncryptedClaims(serviceKeyPair, issuerKeyPair.PublicKey, str)
+func EncryptWithClaims(key interface{}, recipient any, payload any, opts ...*Option) (string, error) {
+	signed, err := SignWithClaims(key, payload, opts...)
+	if err != nil {
+		return "", err
+	}
+	return EncryptToken(recipient, signed)
+}
+
+// ParseEncryptedClaims decrypts the token with the recipient private key,
+// then parses and validates the nested signed token like ParseClaims.
+//
+// Supported decryption keys:
+//   - *ecdh.PrivateKey (X25519), ed25519.PrivateKey, *ed25519.KeyPair
+//   - *rsa.PrivateKey, *rsa.KeyPair
+func ParseEncryptedClaims(key any, pub crypto.PublicKey, str string, opts ...*ParseOption) (*MapClaims, error) {
+	signed, err := DecryptToken(key, str)
+	if err != nil {
+		return nil, err
+	}
+	return ParseClaims(pub, signed, opts...)
+}
+
+// DecryptingVerifier returns a Verifier which decrypts the tokens with the recipient private key
+// (see ParseEncryptedClaims), then verifies the nested signed token with the given verifier.
+func DecryptingVerifier(key any, verifier Verifier) Verifier {
+	return VerifierFunc(func(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error) {
+		signed, err := DecryptToken(key, str)
+		if err != nil {
+			return nil, err
+		}
+		return verifier.Verify(ctx, signed, opts...)
+	})
+}
+
+// EncryptToken encrypts a signed token for the recipient, in the JWE compact serialization
+// with the "cty" header "JWT". See EncryptWithClaims for the supported recipient keys.
+func EncryptToken(recipient any, signed string) (string, error) {
+	header := jweHeader{Enc: EncA256GCM, Cty: contentTypeJWT}
+
+	var cek, encryptedKey []byte
+	switch pub := recipient.(type) {
+	case *rsa.PublicKey:
+		header.Alg = AlgRSAOAEP256
+		var err error
+		if cek, encryptedKey, err = wrapKeyRSA(pub); err != nil {
+			return "", err
+		}
+	case *rsaKeys.KeyPair:
+		if pub == nil || pub.PrivateKey == nil {
+			return "", errors.New("key pair is nil")
+		}
+		return EncryptToken(&pub.PrivateKey.PublicKey, signed)
+	case *rsaKeys.PublicKey:
+		if pub == nil || pub.PublicKey == nil {
+			return "", errors.New("public key is nil")
+		}
+		return EncryptToken(pub.PublicKey, signed)
+	default:
+		x, err := x25519PublicKey(recipient)
+		if err != nil {
+			return "", err
+		}
+		header.Alg = AlgECDHES
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate ephemeral key: %w", err)
+		}
+		z, err := ephemeral.ECDH(x)
+		if err != nil {
+			return "", err
+		}
+		header.Epk = &JSONWebKey{
+			Kty: keyTypeOKP,
+			Crv: curveX25519,
+			X:   base64.RawURLEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+		}
+		cek = concatKDF(z, EncA256GCM, cekSize)
+	}
+
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(rawHeader)
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate IV: %w", err)
+	}
+	// The protected header is the additional authenticated data (RFC 7516, section 5.1)
+	sealed := gcm.Seal(nil, iv, []byte(signed), []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// DecryptToken decrypts a token encrypted by EncryptToken and returns the nested signed token.
+// See ParseEncryptedClaims for the supported decryption keys.
+func DecryptToken(key any, str string) (string, error) {
+	parts := strings.Split(str, ".")
+	if len(parts) != 5 {
+		return "", fmt.Errorf("invalid encrypted token: expected 5 parts, got %d", len(parts))
+	}
+	var decoded [5][]byte
+	for i, part := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return "", fmt.Errorf("invalid encrypted token: %w", err)
+		}
+		decoded[i] = b
+	}
+
+	var header jweHeader
+	if err := json.Unmarshal(decoded[0], &header); err != nil {
+		return "", fmt.Errorf("invalid encrypted token header: %w", err)
+	}
+	if header.Enc != EncA256GCM {
+		return "", fmt.Errorf("unsupported content encryption: %q", header.Enc)
+	}
+	if header.Zip != "" || len(header.Crit) > 0 {
+		return "", errors.New("unsupported encrypted token header")
+	}
+	if header.Cty != "" && !strings.EqualFold(header.Cty, contentTypeJWT) {
+		return "", fmt.Errorf("unexpected content type: %q", header.Cty)
+	}
+
+	var cek []byte
+	switch header.Alg {
+	case AlgRSAOAEP256:
+		priv, err := rsaPrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, priv, decoded[1], nil); err != nil {
+			return "", ErrDecryption
+		}
+	case AlgECDHES:
+		priv, err := x25519PrivateKey(key)
+		if err != nil {
+			return "", err
+		}
+		if header.Epk == nil || header.Epk.Kty != keyTypeOKP || header.Epk.Crv != curveX25519 {
+			return "", errors.New("invalid ephemeral public key")
+		}
+		if len(decoded[1]) != 0 {
+			return "", errors.New("unexpected encrypted key for ECDH-ES")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(header.Epk.X)
+		if err != nil {
+			return "", fmt.Errorf("invalid ephemeral public key: %w", err)
+		}
+		epk, err := ecdh.X25519().NewPublicKey(x)
+		if err != nil {
+			return "", fmt.Errorf("invalid ephemeral public key: %w", err)
+		}
+		z, err := priv.ECDH(epk)
+		if err != nil {
+			return "", ErrDecryption
+		}
+		cek = concatKDF(z, header.Enc, cekSize)
+	default:
+		return "", fmt.Errorf("unsupported key management algorithm: %q", header.Alg)
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	if len(decoded[2]) != gcm.NonceSize() || len(decoded[4]) != gcm.Overhead() {
+		return "", ErrDecryption
+	}
+	plaintext, err := gcm.Open(nil, decoded[2], append(decoded[3], decoded[4]...), []byte(parts[0]))
+	if err != nil {
+		return "", ErrDecryption
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func wrapKeyRSA(pub *rsa.PublicKey) (cek, encryptedKey []byte, err error) {
+	cek = make([]byte, cekSize)
+	if _, err := rand.Read(cek); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate content encryption key: %w", err)
+	}
+	if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, cek, nil); err != nil {
+		return nil, nil, fmt.Errorf("failed to wrap content encryption key: %w", err)
+	}
+	return cek, encryptedKey, nil
+}
+
+// concatKDF derives the key of ECDH-ES with the Concat KDF of NIST SP 800-56A (RFC 7518, section 4.6.2),
+// without PartyUInfo and PartyVInfo. The size must not exceed the size of SHA-256.
+func concatKDF(z []byte, algorithmId string, size int) []byte {
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(1))
+	h.Write(z)
+	_ = binary.Write(h, binary.BigEndian, uint32(len(algorithmId)))
+	h.Write([]byte(algorithmId))
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyUInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(0)) // PartyVInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(size*8))
+	return h.Sum(nil)[:size]
+}
+
+func x25519PublicKey(key any) (*ecdh.PublicKey, error) {
+	switch k := key.(type) {
+	case *ecdh.PublicKey:
+		if k == nil || k.Curve() != ecdh.X25519() {
+			return nil, errors.New("ECDH public key must be X25519")
+		}
+		return k, nil
+	case ed25519.PublicKey:
+		return edKeys.X25519PublicKey(k)
+	case *edKeys.KeyPair:
+		if k == nil {
+			return nil, errors.New("key pair is nil")
+		}
+		return edKeys.X25519PublicKey(k.PublicKey)
+	case *edKeys.PublicKey:
+		if k == nil {
+			return nil, errors.New("public key is nil")
+		}
+		return edKeys.X25519PublicKey(k.PublicKey)
+	default:
+		return nil, fmt.Errorf("unsupported recipient key type: %T", key)
+	}
+}
+
+func x25519PrivateKey(key any) (*ecdh.PrivateKey, error) {
+	switch k := key.(type) {
+	case *ecdh.PrivateKey:
+		if k == nil || k.Curve() != ecdh.X25519() {
+			return nil, errors.New("ECDH private key must be X25519")
+		}
+		return k, nil
+	case ed25519.PrivateKey:
+		return edKeys.X25519PrivateKey(k)
+	case *edKeys.KeyPair:
+		if k == nil {
+			return nil, errors.New("key pair is nil")
+		}
+		return k.X25519()
+	default:
+		return nil, fmt.Errorf("unsupported decryption key type for %s: %T", AlgECDHES, key)
+	}
+}
+
+func rsaPrivateKey(key any) (*rsa.PrivateKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *rsaKeys.KeyPair:
+		if k == nil || k.PrivateKey == nil {
+			return nil, errors.New("key pair is nil")
+		}
+		return k.PrivateKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported decryption key type for %s: %T", AlgRSAOAEP256, key)
+	}
+}
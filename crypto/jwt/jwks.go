@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"github.com/golang-devkit/pkg/crypto/internal/thumbprint"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+const (
+	keyTypeEC  = thumbprint.KeyTypeEC
+	keyTypeRSA = thumbprint.KeyTypeRSA
+	keyTypeOKP = thumbprint.KeyTypeOKP
+
+	curveEd25519 = thumbprint.CurveEd25519
+
+	headerKeyId = "kid"
+)
+
+// JSONWebKey is the public part of a JSON Web Key (RFC 7517).
+//
+// Example:
+//
+//	{
+//		"kty": "OKP",
+//		"kid": "ZhD6rKVgQ3ZoHjNhfOBu5Z1HmkLxNO2oB0RZbZL2kFk",
+//		"use": "sig",
+//		"alg": "EdDSA",
+//		"crv": "Ed25519",
+//		"x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"
+//	}
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is a set of JSON Web Keys (RFC 7517, section 5).
+// It implements http.Handler to publish the set, e.g. at "/.well-known/jwks.json".
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey builds the JSON Web Key of the given public key.
+// Private keys and KeyPairs are accepted too, only their public part is exported.
+// The key ID is the RFC 7638 thumbprint of the key, the same one SignWithClaims puts in the "kid" header.
+func NewJSONWebKey(key any) (*JSONWebKey, error) {
+	pub, algorithms, err := verificationKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := thumbprint.PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	jwk := &JSONWebKey{
+		Kty: members.Kty,
+		Use: "sig",
+		Crv: members.Crv,
+		X:   members.X,
+		Y:   members.Y,
+		N:   members.N,
+		E:   members.E,
+	}
+	switch pub.(type) {
+	case ed25519.PublicKey:
+		jwk.Alg = AlgEdDSA
+	case *ecdsa.PublicKey:
+		jwk.Alg = algorithms[0]
+	}
+
+	if jwk.Kid, err = jwk.Thumbprint(); err != nil {
+		return nil, err
+	}
+	return jwk, nil
+}
+
+// Thumbprint computes the RFC 7638 thumbprint (SHA-256, base64url) of the key.
+func (k *JSONWebKey) Thumbprint() (string, error) {
+	return thumbprint.Key{Kty: k.Kty, Crv: k.Crv, X: k.X, Y: k.Y, E: k.E, N: k.N}.Sum()
+}
+
+// PublicKey decodes the public key of the JSON Web Key.
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case keyTypeOKP:
+		if k.Crv != curveEd25519 {
+			return nil, fmt.Errorf("unsupported OKP curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case keyTypeEC:
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode y: %w", err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinate size")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, slices.Concat([]byte{4}, x, y))
+	case keyTypeRSA:
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode e: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+// KeyId returns the RFC 7638 thumbprint of the (public part of the) key.
+func KeyId(key any) (string, error) {
+	jwk, err := NewJSONWebKey(key)
+	if err != nil {
+		return "", err
+	}
+	return jwk.Kid, nil
+}
+
+// NewJSONWebKeySet builds a JSON Web Key Set from the given keys,
+// e.g. *ed25519.KeyPair and *rsa.KeyPair of this module, or their public keys.
+func NewJSONWebKeySet(keys ...any) (*JSONWebKeySet, error) {
+	set := &JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(keys))}
+	for i, key := range keys {
+		jwk, err := NewJSONWebKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
+
+// Key returns the key with the given key ID.
+func (s *JSONWebKeySet) Key(kid string) (*JSONWebKey, bool) {
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// ServeHTTP publishes the JSON Web Key Set.
+func (s *JSONWebKeySet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jwk-set+json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		logger.NewEntry().Warn("Không thể ghi JWKS", zap.String(logger.KeyError, err.Error()))
+	}
+}
+
+// JWKSHandler returns a http.Handler publishing the JSON Web Key Set of the given keys.
+//
+// Example:
+//
+//	handler, err := jwt.JWKSHandler(edKeyPair, rsaKeyPair)
+//	if err != nil {
+//		return err
+//	}
+//	router.Handle("/.well-known/jwks.json", handler)
+func JWKSHandler(keys ...any) (http.Handler, error) {
+	set, err := NewJSONWebKeySet(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,155 @@
+package jwt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+func TestJWKSVerifier(t *testing.T) {
+	t.Parallel()
+
+	edPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPair, err := rsaKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := JWKSHandler(edPair, rsaPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx := context.Background()
+	verifier, err := NewJWKSVerifier(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(verifier.KeyIds()); n != 2 {
+		t.Fatalf("expected 2 keys, got %d", n)
+	}
+
+	for _, key := range []any{edPair, rsaPair.PrivateKey} {
+		str, err := SignWithClaims(key, nil, NewOption().SetUserId("u-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := verifier.Verify(ctx, str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.UserId != "u-1" {
+			t.Fatalf("unexpected user id: %s", claims.UserId)
+		}
+	}
+
+	// Token signed by a key which is not published
+	other, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	str, err := SignWithClaims(other.PrivateKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(ctx, str); err == nil {
+		t.Fatal("expected unknown key to be rejected")
+	}
+}
+
+func TestJWKSVerifierRefreshOnce(t *testing.T) {
+	t.Parallel()
+
+	edPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := JWKSHandler(edPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	verifier, err := NewJWKSVerifier(ctx, server.URL, NewJWKSOption().SetMinRefreshInterval(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As if the key set was loaded long ago
+	verifier.fetchMu.Lock()
+	verifier.attemptedAt = time.Time{}
+	verifier.fetchMu.Unlock()
+
+	// Concurrent tokens with forged key IDs load the key set once
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			str, err := SignWithClaims(edPair, nil, NewOption().SetKeyId("forged-"+strconv.Itoa(i)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := verifier.Verify(ctx, str); err == nil {
+				t.Error("expected unknown key to be rejected")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := downloads.Load(); n != 2 {
+		t.Fatalf("expected 2 downloads, got %d", n)
+	}
+}
+
+func TestJWKSVerifierFromFile(t *testing.T) {
+	t.Parallel()
+
+	edPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	set, err := NewJSONWebKeySet(edPair.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/jwks.json", nil))
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, rec.Body.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := NewJWKSVerifier(context.Background(), "file://"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	str, err := SignWithClaims(edPair.PrivateKey, nil, NewOption().SetKeyId(set.Keys[0].Kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(context.Background(), str); err != nil {
+		t.Fatal(err)
+	}
+}
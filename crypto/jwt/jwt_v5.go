@@ -1,8 +1,8 @@
 package jwt
 
 import (
-	"crypto/ecdsa"
-	"crypto/ed25519"
+	"context"
+	"crypto"
 	"errors"
 	"fmt"
 	"time"
@@ -18,48 +18,66 @@ const (
 	subject = "Bankaool, S.A., Institución de Banca Múltiple"
 )
 
+// SignWithClaims signs the payload with the given private key.
+// The signing algorithm is picked from the key type (EdDSA, ES256/384/512, RS256 or the one set by Option.SetAlgorithm),
+// see signingMethod for the supported key types.
 func SignWithClaims(key interface{}, payload any, opts ...*Option) (string, error) {
 
-	opt := NewOption()
-	for i, op := range opts {
-		if op.liveTime > 0 && (i == 0 || op.liveTime < opt.liveTime) {
-			opt = opt.SetLiveTime(op.liveTime)
-		}
-		if i == 0 && op.sessionId != "" {
-			opt = opt.SetSessionId(op.sessionId)
-		}
-		if i == 0 && op.userId != "" {
-			opt = opt.SetUserId(op.userId)
-		}
-	}
+	opt := mergeOption(opts)
+
+	claims := newClaims(opt)
+	claims.Payload = payload
 
-	claims := MapClaims{
+	return signClaims(key, &claims, opt)
+}
+
+// newClaims returns the claims of a new token, without payload.
+func newClaims(opt *Option) MapClaims {
+	now := time.Now()
+	return MapClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        uuid.NewString(),
-			Issuer:    issue,
-			Subject:   subject,
-			Audience:  []string{"LOGIN"},
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(opt.LiveTime())),
+			Issuer:    opt.Issuer(),
+			Subject:   opt.Subject(),
+			Audience:  opt.Audience(),
+			NotBefore: jwt.NewNumericDate(now.Add(opt.NotBeforeOffset())),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(opt.LiveTime())),
 		},
-		SessionId: opt.SessionId(),
-		UserId:    opt.UserId(),
-		Payload:   payload,
+		SessionId:        opt.SessionId(),
+		UserId:           opt.UserId(),
+		ProtobufDataHash: opt.ProtoDataHash(),
+		TenantId:         opt.TenantId(),
+		Roles:            opt.Roles(),
+		Scopes:           opt.Scopes(),
+		Confirmation:     confirmation(opt.DPoPThumbprint()),
 	}
+}
 
-	var method jwt.SigningMethod
-	switch key.(type) {
-	case ecdsa.PrivateKey:
-		method = jwt.SigningMethodES256
-	case ed25519.PrivateKey:
-		method = jwt.SigningMethodEdDSA
-	default:
-		return "", errors.New("only support ecdsa or ed25519 key")
+// signClaims signs the claims with the given private key, see SignWithClaims.
+func signClaims(key interface{}, claims jwt.Claims, opt *Option) (string, error) {
+
+	method, signKey, err := signingMethod(key, opt.Algorithm())
+	if err != nil {
+		return "", err
 	}
 
 	// Create a new JWT value
-	return jwt.NewWithClaims(method, &claims).SignedString(key)
+	token := jwt.NewWithClaims(method, claims)
+
+	// Set the key ID, by default the ID of the key (e.g. keystore.Signer), or the RFC 7638 thumbprint of the public key
+	kid := opt.KeyId()
+	if k, ok := key.(interface{ KeyID() string }); ok && kid == "" {
+		kid = k.KeyID()
+	}
+	if kid == "" {
+		if kid, err = KeyId(signKey); err != nil {
+			return "", err
+		}
+	}
+	token.Header[headerKeyId] = kid
+
+	return token.SignedString(signKey)
 }
 
 func ParseClaimWithoutSign(jwtStr string) (*MapClaims, error) {
@@ -75,14 +93,19 @@ func ParseClaimWithoutSign(jwtStr string) (*MapClaims, error) {
 
 // ParseClaimsWithoutValidation parses the JWT claims without validating the claims (like exp, nbf, iat).
 // It only verifies the signature using the provided public key.
-func ParseClaimsWithoutValidation(pub ed25519.PublicKey, str string) (*MapClaims, error) {
+// Only the algorithm allowlist of ParseOption is applied.
+func ParseClaimsWithoutValidation(pub crypto.PublicKey, str string, opts ...*ParseOption) (*MapClaims, error) {
 
-	parsedToken, err := jwt.ParseWithClaims(str, &MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodEd25519); ok {
-			return pub, nil
-		}
-		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-	}, jwt.WithoutClaimsValidation())
+	key, algorithms, err := verificationKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if algorithms, err = mergeParseOption(opts).allowedAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	parsedToken, err := jwt.ParseWithClaims(str, &MapClaims{}, keyfunc(key, algorithms),
+		jwt.WithValidMethods(algorithms), jwt.WithoutClaimsValidation())
 
 	// Make sure they JWT claims without validating the claims
 	switch {
@@ -104,40 +127,79 @@ func ParseClaimsWithoutValidation(pub ed25519.PublicKey, str string) (*MapClaims
 
 // ParseClaims parses the JWT claims and validates the claims (like exp, nbf, iat).
 // It verifies the signature using the provided public key.
-func ParseClaims(pub ed25519.PublicKey, str string) (*MapClaims, error) {
+// The expected issuer, accepted audiences and clock-skew leeway can be set with ParseOption.
+//
+// The accepted algorithms are derived from the type of the public key (ed25519, ecdsa or rsa)
+// and can be restricted further with ParseOption.SetAlgorithms.
+func ParseClaims(pub crypto.PublicKey, str string, opts ...*ParseOption) (*MapClaims, error) {
+
+	opt := mergeParseOption(opts)
+	key, algorithms, err := verificationKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if algorithms, err = opt.allowedAlgorithms(algorithms); err != nil {
+		return nil, err
+	}
+
+	return parseClaims(context.Background(), "ParseClaims", str, keyfunc(key, algorithms), opt, jwt.WithValidMethods(algorithms))
+}
+
+// parseClaims parses and validates the JWT claims with the given key function,
+// then checks the revocation store of the option if any.
+// It is shared by ParseClaims and the verifiers of this package.
+func parseClaims(ctx context.Context, funcName, str string, kf jwt.Keyfunc, opt *ParseOption, parserOpts ...jwt.ParserOption) (*MapClaims, error) {
+	claims := &MapClaims{}
+	if err := parseClaimsInto(ctx, funcName, str, claims, kf, opt, parserOpts...); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// claimsHolder is implemented by *MapClaims and *Claims[T].
+type claimsHolder interface {
+	jwt.Claims
+	mapClaims() *MapClaims
+}
+
+// parseClaimsInto parses and validates the token into claims.
+func parseClaimsInto(ctx context.Context, funcName, str string, claims claimsHolder, kf jwt.Keyfunc, opt *ParseOption, parserOpts ...jwt.ParserOption) error {
 
 	entry := logger.NewEntry().With(
-		zap.String(logger.KeyFunctionName, "ParseClaims"),
+		zap.String(logger.KeyFunctionName, funcName),
 		zap.String(logger.KeyJwtString, str),
 	)
 
-	parsedToken, err := jwt.ParseWithClaims(str, &MapClaims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodEd25519); ok {
-			return pub, nil
-		}
-		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-	})
+	_, err := jwt.ParseWithClaims(str, claims, kf,
+		append(opt.parserOptions(), parserOpts...)...)
 
 	switch {
 	case errors.Is(err, jwt.ErrTokenExpired):
 		entry.Warn("Token hết hạn", zap.String(logger.KeyError, err.Error()))
-		return nil, err
+		return err
 	case errors.Is(err, jwt.ErrTokenNotValidYet):
 		entry.Warn("Token chưa đến thời điểm hợp lệ (nbf)", zap.String(logger.KeyError, err.Error()))
-		return nil, err
+		return err
 	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
 		entry.Warn("Token được sử dụng trước khi được phát hành (iat)", zap.String(logger.KeyError, err.Error()))
-		return nil, err
+		return err
 	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
 		entry.Warn("Chữ ký không hợp lệ", zap.String(logger.KeyError, err.Error()))
-		return nil, err
+		return err
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		entry.Warn("Issuer không hợp lệ (iss)", zap.String(logger.KeyError, err.Error()))
+		return err
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		entry.Warn("Audience không hợp lệ (aud)", zap.String(logger.KeyError, err.Error()))
+		return err
 	default:
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if claim, ok := parsedToken.Claims.(*MapClaims); ok {
-			return claim, nil
+		if err := checkRevocation(ctx, opt.RevocationStore(), claims.mapClaims()); err != nil {
+			entry.Warn("Token đã bị thu hồi", zap.String(logger.KeyError, err.Error()))
+			return err
 		}
-		return nil, fmt.Errorf("invalid token claims")
+		return nil
 	}
 }
@@ -0,0 +1,612 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+)
+
+func TestSignWithClaimsRegisteredClaims(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opt := NewOption().
+		SetIssuer("auth.example.com").
+		SetSubject("user-login").
+		SetAudience("PAYMENT", "TRANSFER")
+
+	str, err := SignWithClaims(key, map[string]any{"k": "v"}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseClaims(pub, str, NewParseOption().
+		SetIssuer("auth.example.com").
+		SetAudience("TRANSFER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Issuer != "auth.example.com" || claims.Subject != "user-login" {
+		t.Fatalf("unexpected registered claims: %#v", claims.RegisteredClaims)
+	}
+
+	if _, err := ParseClaims(pub, str, NewParseOption().SetAudience("LOGIN")); !errors.Is(err, jwt.ErrTokenInvalidAudience) {
+		t.Fatalf("expected invalid audience, got %v", err)
+	}
+	if _, err := ParseClaims(pub, str, NewParseOption().SetIssuer("other")); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Fatalf("expected invalid issuer, got %v", err)
+	}
+}
+
+func TestSignWithClaimsNotBeforeOffset(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	str, err := SignWithClaims(key, nil, NewOption().SetNotBeforeOffset(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseClaims(pub, str); !errors.Is(err, jwt.ErrTokenNotValidYet) {
+		t.Fatalf("expected token not valid yet, got %v", err)
+	}
+	if _, err := ParseClaims(pub, str, NewParseOption().SetLeeway(2*time.Minute)); err != nil {
+		t.Fatalf("expected leeway to accept token, got %v", err)
+	}
+}
+
+// opaqueSigner hides the concrete key type, like a key held by a KMS.
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+func TestSignWithClaimsKeyTypes(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPair, err := ecKeys.GenerateKeyPair(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  any
+		pub  crypto.PublicKey
+		opt  *Option
+		alg  string
+	}{
+		{name: "RS256", key: rsaKey, pub: &rsaKey.PublicKey, opt: NewOption(), alg: AlgRS256},
+		{name: "PS256", key: rsaKey, pub: &rsaKey.PublicKey, opt: NewOption().SetAlgorithm(AlgPS256), alg: AlgPS256},
+		{name: "ES384", key: ecKey, pub: &ecKey.PublicKey, opt: NewOption(), alg: AlgES384},
+		{name: "ES384 value", key: *ecKey, pub: ecKey.PublicKey, opt: NewOption(), alg: AlgES384},
+		{name: "ES256 key pair", key: ecPair, pub: ecPair.ToPublic(), opt: NewOption(), alg: AlgES256},
+		{name: "signer ES384", key: opaqueSigner{ecKey}, pub: &ecKey.PublicKey, opt: NewOption(), alg: AlgES384},
+		{name: "signer PS256", key: opaqueSigner{rsaKey}, pub: &rsaKey.PublicKey, opt: NewOption().SetAlgorithm(AlgPS256), alg: AlgPS256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := SignWithClaims(tt.key, nil, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(str, &MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Method.Alg() != tt.alg {
+				t.Fatalf("expected alg %s, got %s", tt.alg, token.Method.Alg())
+			}
+			if _, err := ParseClaims(tt.pub, str); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseClaims(tt.pub, str, NewParseOption().SetAlgorithms(AlgEdDSA)); err == nil {
+				t.Fatal("expected algorithm outside of the allowlist to be rejected")
+			}
+		})
+	}
+
+	if _, err := SignWithClaims(ecKey, nil, NewOption().SetAlgorithm(AlgES256)); err == nil {
+		t.Fatal("expected mismatched algorithm to be rejected")
+	}
+}
+
+func TestParseClaimsRevocation(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store := NewMemoryRevocationStore()
+	parseOpt := NewParseOption().SetRevocationStore(store)
+
+	sign := func(sessionId, userId string) (string, *MapClaims) {
+		str, err := SignWithClaims(key, nil, NewOption().SetSessionId(sessionId).SetUserId(userId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		claims, err := ParseClaims(pub, str, parseOpt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str, claims
+	}
+
+	// Revoke a single token
+	str, claims := sign("s-1", "u-1")
+	if err := RevokeClaims(ctx, store, claims); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseClaims(pub, str, parseOpt); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected revoked token, got %v", err)
+	}
+	if _, err := ParseClaims(pub, str); err != nil {
+		t.Fatalf("expected token accepted without revocation store, got %v", err)
+	}
+
+	// Revoke every token of a session
+	str, _ = sign("s-2", "u-2")
+	if err := store.RevokeSession(ctx, "s-2", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseClaims(pub, str, parseOpt); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected revoked session, got %v", err)
+	}
+
+	// Revoke every token of a user
+	str, _ = sign("s-3", "u-3")
+	if err := store.RevokeUser(ctx, "u-3", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseClaims(pub, str, parseOpt); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected revoked user, got %v", err)
+	}
+}
+
+func TestRefreshIssuerRotation(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	revocations := NewMemoryRevocationStore()
+	issuer := NewRefreshIssuer(key, NewMemoryRefreshStore()).SetRevocationStore(revocations)
+
+	first, err := issuer.Issue(ctx, map[string]string{"role": "teller"}, NewOption().SetUserId("u-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := issuer.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("expected refresh token to be rotated")
+	}
+
+	claims1, err := ParseClaims(pub, first.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims2, err := ParseClaims(pub, second.AccessToken, NewParseOption().SetRevocationStore(revocations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims1.SessionId != claims2.SessionId || claims2.UserId != "u-1" {
+		t.Fatalf("expected same session and user, got %#v and %#v", claims1, claims2)
+	}
+	var payload map[string]string
+	if err := claims2.ParsePayload(&payload); err != nil || payload["role"] != "teller" {
+		t.Fatalf("unexpected payload: %v (%v)", payload, err)
+	}
+
+	// Reuse of the rotated token invalidates the whole session
+	if _, err := issuer.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("expected reuse detection, got %v", err)
+	}
+	if _, err := issuer.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("expected session to be invalidated, got %v", err)
+	}
+	if _, err := ParseClaims(pub, second.AccessToken, NewParseOption().SetRevocationStore(revocations)); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("expected access token to be revoked, got %v", err)
+	}
+}
+
+func TestRefreshIssuerDPoP(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jkt, err := DPoPThumbprint(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	issuer := NewRefreshIssuer(key, NewMemoryRefreshStore())
+	dpop := NewDPoPVerifier()
+	const tokenURL = "https://api.example.com/oauth/token"
+	proof := func(key ed25519.PrivateKey) string {
+		str, err := NewDPoPProof(key, http.MethodPost, tokenURL, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+	req := DPoPRequest{Method: http.MethodPost, URL: tokenURL}
+
+	first, err := issuer.Issue(ctx, nil, NewOption().SetUserId("u-1").SetAudience("PAYMENT").
+		SetTenantId("t-1").SetRoles("teller").SetScopes("payment:read").SetDPoPThumbprint(jkt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without proof the refresh token of a bound session is rejected, and the session invalidated
+	if _, err := issuer.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrDPoPKeyMismatch) {
+		t.Fatalf("expected key mismatch without proof, got %v", err)
+	}
+	if _, err := issuer.RefreshWithDPoP(ctx, first.RefreshToken, dpop, proof(clientKey), req); err == nil {
+		t.Fatal("expected the session to be invalidated")
+	}
+
+	first, err = issuer.Issue(ctx, nil, NewOption().SetUserId("u-1").SetAudience("PAYMENT").
+		SetTenantId("t-1").SetRoles("teller").SetScopes("payment:read").SetDPoPThumbprint(jkt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The claims of the session are kept, whatever the options of the caller
+	second, err := issuer.RefreshWithDPoP(ctx, first.RefreshToken, dpop, proof(clientKey), req, NewOption().SetScopes("payment:write"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseClaims(pub, second.AccessToken, NewParseOption().SetAudience("PAYMENT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.TenantId != "t-1" || !slices.Equal(claims.Roles, []string{"teller"}) || !slices.Equal(claims.Scopes, []string{"payment:read"}) {
+		t.Fatalf("unexpected claims: %#v", claims)
+	}
+	if err := claims.VerifyDPoPBinding(jkt); err != nil {
+		t.Fatal(err)
+	}
+
+	// A proof signed by another key
+	if _, err := issuer.RefreshWithDPoP(ctx, second.RefreshToken, dpop, proof(otherKey), req); !errors.Is(err, ErrDPoPKeyMismatch) {
+		t.Fatalf("expected key mismatch, got %v", err)
+	}
+}
+
+// sessionRevocations records the expiry of the session revocations.
+type sessionRevocations struct {
+	*MemoryRevocationStore
+	expiresAt map[string]time.Time
+}
+
+func (s *sessionRevocations) RevokeSession(ctx context.Context, sessionId string, expiresAt time.Time) error {
+	s.expiresAt[sessionId] = expiresAt
+	return s.MemoryRevocationStore.RevokeSession(ctx, sessionId, expiresAt)
+}
+
+func TestRefreshIssuerRevoke(t *testing.T) {
+	t.Parallel()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	revocations := &sessionRevocations{MemoryRevocationStore: NewMemoryRevocationStore(), expiresAt: map[string]time.Time{}}
+	issuer := NewRefreshIssuer(key, NewMemoryRefreshStore()).SetRevocationStore(revocations)
+
+	// The access tokens live longer than the default live time
+	opt := NewOption().SetUserId("u-1").SetSessionId("s-1").SetLiveTime(time.Hour)
+	first, err := issuer.Issue(ctx, nil, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := issuer.Refresh(ctx, first.RefreshToken, opt.SetLiveTime(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Revoked until the last access token of the session expires, whatever the options of the caller
+	if err := issuer.Revoke(ctx, "s-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := revocations.expiresAt["s-1"]; !got.Equal(second.ExpiresAt) {
+		t.Fatalf("expected the session to be revoked until %v, got %v", second.ExpiresAt, got)
+	}
+}
+
+func TestSignWithProtoMessage(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := structpb.NewStruct(map[string]any{"from": "A", "to": "B", "amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const method = "/payment.v1.PaymentService/Transfer"
+	str, err := SignWithProtoMessage(key, method, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseClaims(pub, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := ProtobufDataHash(method, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.VerifyProtobufDataHash(hash); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered, err := structpb.NewStruct(map[string]any{"from": "A", "to": "C", "amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash, err = ProtobufDataHash(method, tampered); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.VerifyProtobufDataHash(hash); !errors.Is(err, ErrProtobufDataHashMismatch) {
+		t.Fatalf("expected hash mismatch, got %v", err)
+	}
+
+	// The same message sent to another method
+	if hash, err = ProtobufDataHash("/payment.v1.PaymentService/Refund", req); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.VerifyProtobufDataHash(hash); !errors.Is(err, ErrProtobufDataHashMismatch) {
+		t.Fatalf("expected hash mismatch for another method, got %v", err)
+	}
+}
+
+func TestSignParseTyped(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type transfer struct {
+		From   string `json:"from"`
+		Amount int64  `json:"amount"`
+	}
+	str, err := Sign(key, transfer{From: "A", Amount: 100}, NewOption().SetUserId("user-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := Parse[transfer](pub, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Payload.From != "A" || claims.Payload.Amount != 100 || claims.UserId != "user-1" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// The typed payload is compatible with MapClaims
+	mapClaims, err := ParseClaims(pub, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v transfer
+	if err := mapClaims.ParsePayload(&v); err != nil || v != claims.Payload {
+		t.Fatalf("unexpected payload: %+v, %v", v, err)
+	}
+
+	msg, err := structpb.NewStruct(map[string]any{"from": "A", "amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if str, err = Sign(key, msg); err != nil {
+		t.Fatal(err)
+	}
+	msgClaims, err := Parse[*structpb.Struct](pub, str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msgClaims.Payload.GetFields()["from"].GetStringValue(); got != "A" {
+		t.Fatalf("unexpected payload: %v", msgClaims.Payload)
+	}
+
+	if _, err := Parse[transfer](pub, str+"x"); err == nil {
+		t.Fatal("expected an error for an invalid signature")
+	}
+}
+
+func TestEncryptWithClaims(t *testing.T) {
+	t.Parallel()
+
+	issuerPub, issuerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edRecipient, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaRecipient, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The converted X25519 keys must match
+	x25519Key, err := edRecipient.X25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x25519Pub, err := edKeys.X25519PublicKey(edRecipient.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !x25519Key.PublicKey().Equal(x25519Pub) {
+		t.Fatal("X25519 public key does not match the converted private key")
+	}
+
+	tests := []struct {
+		name      string
+		recipient any
+		key       any
+	}{
+		{name: AlgECDHES, recipient: edRecipient.PublicKey, key: edRecipient},
+		{name: AlgRSAOAEP256, recipient: &rsaRecipient.PublicKey, key: rsaRecipient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := EncryptWithClaims(issuerKey, tt.recipient, map[string]any{"account": "0001"}, NewOption().SetUserId("user-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseClaimWithoutSign(str); err == nil {
+				t.Fatal("expected the claims to be unreadable without key")
+			}
+
+			claims, err := ParseEncryptedClaims(tt.key, issuerPub, str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.UserId != "user-1" {
+				t.Fatalf("unexpected userId: %q", claims.UserId)
+			}
+
+			// Tamper the authentication tag
+			parts := strings.Split(str, ".")
+			tag, err := base64.RawURLEncoding.DecodeString(parts[4])
+			if err != nil {
+				t.Fatal(err)
+			}
+			tag[0] ^= 1
+			parts[4] = base64.RawURLEncoding.EncodeToString(tag)
+			if _, err := ParseEncryptedClaims(tt.key, issuerPub, strings.Join(parts, ".")); !errors.Is(err, ErrDecryption) {
+				t.Fatalf("expected decryption error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDPoP(t *testing.T) {
+	t.Parallel()
+
+	issuerPub, issuerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewDPoPVerifier()
+	ctx := context.Background()
+	const tokenURL, apiURL = "https://auth.example.com/token", "https://api.example.com/accounts/1"
+
+	// Token endpoint: bind the token to the key of the proof
+	proof, err := NewDPoPProof(clientKey, "POST", tokenURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jkt, err := verifier.Verify(ctx, proof, DPoPRequest{Method: "POST", URL: tokenURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := DPoPThumbprint(clientKey); jkt != want {
+		t.Fatalf("unexpected thumbprint: %s", jkt)
+	}
+	accessToken, err := SignWithClaims(issuerKey, nil, NewOption().SetDPoPThumbprint(jkt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseClaims(issuerPub, accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resource server
+	proof, err = NewDPoPProof(clientKey, "GET", apiURL+"?page=2", accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := DPoPRequest{Method: "GET", URL: apiURL, AccessToken: accessToken}
+	if _, err := verifier.Verify(ctx, proof, DPoPRequest{Method: "DELETE", URL: apiURL, AccessToken: accessToken}); !errors.Is(err, ErrDPoPProofInvalid) {
+		t.Fatalf("expected invalid proof for another method, got %v", err)
+	}
+	if _, err := verifier.Verify(ctx, proof, DPoPRequest{Method: "GET", URL: apiURL, AccessToken: "other"}); !errors.Is(err, ErrDPoPProofInvalid) {
+		t.Fatalf("expected invalid proof for another token, got %v", err)
+	}
+	if jkt, err = verifier.Verify(ctx, proof, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.VerifyDPoPBinding(jkt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(ctx, proof, req); !errors.Is(err, ErrDPoPProofReplayed) {
+		t.Fatalf("expected replayed proof, got %v", err)
+	}
+
+	// A proof of another key
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof, err = NewDPoPProof(otherKey, "GET", apiURL, accessToken); err != nil {
+		t.Fatal(err)
+	}
+	if jkt, err = verifier.Verify(ctx, proof, req); err != nil {
+		t.Fatal(err)
+	}
+	if err := claims.VerifyDPoPBinding(jkt); !errors.Is(err, ErrDPoPKeyMismatch) {
+		t.Fatalf("expected key mismatch, got %v", err)
+	}
+}
@@ -0,0 +1,284 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyWindow defines when a key of the Keyring is in use.
+//
+//   - NotBefore: the key is not used to sign before this time (zero means immediately).
+//   - NotAfter: the key is retired after this time, it neither signs nor verifies anymore (zero means never).
+type KeyWindow struct {
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (w KeyWindow) activeAt(t time.Time) bool {
+	return !t.Before(w.NotBefore) && !w.retiredAt(t)
+}
+
+func (w KeyWindow) retiredAt(t time.Time) bool {
+	return !w.NotAfter.IsZero() && !t.Before(w.NotAfter)
+}
+
+type keyringEntry struct {
+	id         string
+	signer     crypto.Signer // nil for verification-only keys
+	pub        crypto.PublicKey
+	algorithms []string
+	window     KeyWindow
+	retired    bool
+}
+
+// Keyring holds several signing keys identified by their key ID, to rotate keys without a flag-day redeploy.
+//
+// Tokens are signed with the current key and verified with any key which is not retired,
+// the key is selected by the "kid" header of the token.
+//
+// Example:
+//
+//	ring := jwt.NewKeyring()
+//	_ = ring.AddPEMFile("2026-01", "keys/2026-01.pem", jwt.KeyWindow{NotAfter: rotation.Add(24 * time.Hour)})
+//	_ = ring.AddPEMFile("2026-02", "keys/2026-02.pem", jwt.KeyWindow{NotBefore: rotation})
+//
+//	str, err := ring.Sign(payload, jwt.NewOption().SetUserId(userId))
+//	claims, kid, err := ring.VerifyWithKeyId(ctx, str)
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]*keyringEntry
+	current string
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]*keyringEntry)}
+}
+
+// Add adds a key to the keyring.
+// A private key (or crypto.Signer, or KeyPair of this module) can sign and verify, a public key can only verify.
+func (r *Keyring) Add(kid string, key any, window KeyWindow) error {
+	if kid == "" {
+		return errors.New("key ID is required")
+	}
+	if !window.NotAfter.IsZero() && !window.NotAfter.After(window.NotBefore) {
+		return fmt.Errorf("key %s: NotAfter must be after NotBefore", kid)
+	}
+
+	entry := &keyringEntry{id: kid, window: window}
+	if _, signKey, err := signingMethod(key, ""); err == nil {
+		entry.signer, _ = signKey.(crypto.Signer)
+	}
+	pub, algorithms, err := verificationKey(key)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", kid, err)
+	}
+	entry.pub, entry.algorithms = pub, algorithms
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[kid]; ok {
+		return fmt.Errorf("key %s already exists", kid)
+	}
+	r.keys[kid] = entry
+	return nil
+}
+
+// AddPEM adds a key encoded in PEM, e.g. the public or private output of KeyPair.PEM().
+// Supported blocks: "PRIVATE KEY" (PKCS #8), "RSA PRIVATE KEY" (PKCS #1), "EC PRIVATE KEY" (SEC 1) and "PUBLIC KEY" (PKIX).
+func (r *Keyring) AddPEM(kid string, data []byte, window KeyWindow) error {
+	key, err := parsePEMKey(data)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", kid, err)
+	}
+	return r.Add(kid, key, window)
+}
+
+// AddPEMFile adds a key from a PEM file, see AddPEM.
+func (r *Keyring) AddPEMFile(kid, path string, window KeyWindow) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("key %s: %w", kid, err)
+	}
+	return r.AddPEM(kid, data, window)
+}
+
+func parsePEMKey(data []byte) (any, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+// SetCurrent selects the key used to sign, it must be a private key.
+// Without current key, the most recently activated private key is used.
+func (r *Keyring) SetCurrent(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown key ID: %q", kid)
+	}
+	if entry.signer == nil {
+		return fmt.Errorf("key %s has no private key", kid)
+	}
+	if entry.retired {
+		return fmt.Errorf("key %s is retired", kid)
+	}
+	r.current = kid
+	return nil
+}
+
+// Retire retires the key immediately, tokens signed by it are rejected from now on.
+func (r *Keyring) Retire(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("unknown key ID: %q", kid)
+	}
+	entry.retired = true
+	if r.current == kid {
+		r.current = ""
+	}
+	return nil
+}
+
+// Current returns the ID of the key used to sign at the moment.
+func (r *Keyring) Current() (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, err := r.currentEntry(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return entry.id, nil
+}
+
+func (r *Keyring) currentEntry(now time.Time) (*keyringEntry, error) {
+	if entry, ok := r.keys[r.current]; ok && !entry.retired && entry.window.activeAt(now) {
+		return entry, nil
+	}
+	var latest *keyringEntry
+	for _, entry := range r.keys {
+		if entry.signer == nil || entry.retired || !entry.window.activeAt(now) {
+			continue
+		}
+		if latest == nil || entry.window.NotBefore.After(latest.window.NotBefore) ||
+			(entry.window.NotBefore.Equal(latest.window.NotBefore) && entry.id > latest.id) {
+			latest = entry
+		}
+	}
+	if latest == nil {
+		return nil, errors.New("no active signing key in keyring")
+	}
+	return latest, nil
+}
+
+// Sign signs the payload with the current key, the "kid" header is the ID of the key in the keyring.
+func (r *Keyring) Sign(payload any, opts ...*Option) (string, error) {
+	r.mu.RLock()
+	entry, err := r.currentEntry(time.Now())
+	r.mu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+	return SignWithClaims(entry.signer, payload, mergeOption(opts).SetKeyId(entry.id))
+}
+
+// Verify implements Verifier, see VerifyWithKeyId.
+func (r *Keyring) Verify(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error) {
+	claims, _, err := r.VerifyWithKeyId(ctx, str, opts...)
+	return claims, err
+}
+
+// VerifyWithKeyId parses and validates the token with the key selected by its "kid" header,
+// and returns the ID of the key which verified the token.
+func (r *Keyring) VerifyWithKeyId(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, string, error) {
+	opt := mergeParseOption(opts)
+
+	var kid string
+	claims, err := parseClaims(ctx, "Keyring.Verify", str, func(t *jwt.Token) (interface{}, error) {
+		kid, _ = t.Header[headerKeyId].(string)
+
+		r.mu.RLock()
+		entry, ok := r.keys[kid]
+		retired := ok && (entry.retired || entry.window.retiredAt(time.Now()))
+		r.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID: %q", kid)
+		}
+		if retired {
+			return nil, fmt.Errorf("key %s is retired", kid)
+		}
+		algorithms, err := opt.allowedAlgorithms(entry.algorithms)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(algorithms, t.Method.Alg()) {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return entry.pub, nil
+	}, opt)
+	if err != nil {
+		return nil, "", err
+	}
+	return claims, kid, nil
+}
+
+// KeyIds returns the IDs of the keys which are not retired.
+func (r *Keyring) KeyIds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	ids := make([]string, 0, len(r.keys))
+	for kid, entry := range r.keys {
+		if !entry.retired && !entry.window.retiredAt(now) {
+			ids = append(ids, kid)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// JWKS returns the JSON Web Key Set of the keys which are not retired,
+// with the key IDs of the keyring.
+func (r *Keyring) JWKS() (*JSONWebKeySet, error) {
+	set := &JSONWebKeySet{}
+	for _, kid := range r.KeyIds() {
+		r.mu.RLock()
+		entry, ok := r.keys[kid]
+		r.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		jwk, err := NewJSONWebKey(entry.pub)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		jwk.Kid = kid
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set, nil
+}
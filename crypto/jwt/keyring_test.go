@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	t.Parallel()
+
+	oldPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPair, err := rsaKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, oldPEM, err := oldPair.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, newPEM, err := newPair.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ring := NewKeyring()
+	if err := ring.AddPEM("old", oldPEM, KeyWindow{}); err != nil {
+		t.Fatal(err)
+	}
+	// Published a minute ago, so the verifiers already know it
+	if err := ring.AddPEM("new", newPEM, KeyWindow{NotBefore: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	// Not activated yet
+	if err := ring.AddPEM("next", newPEM, KeyWindow{NotBefore: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ring.SetCurrent("old"); err != nil {
+		t.Fatal(err)
+	}
+	if kid, err := ring.Current(); err != nil || kid != "old" {
+		t.Fatalf("expected current key old, got %q (%v)", kid, err)
+	}
+	oldToken, err := ring.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ring.SetCurrent("next"); err != nil {
+		t.Fatal(err)
+	}
+	if kid, _ := ring.Current(); kid == "next" {
+		t.Fatal("expected key not activated yet to be skipped")
+	}
+
+	// Rotate
+	if err := ring.SetCurrent("new"); err != nil {
+		t.Fatal(err)
+	}
+	if kid, err := ring.Current(); err != nil || kid != "new" {
+		t.Fatalf("expected current key new, got %q (%v)", kid, err)
+	}
+	newToken, err := ring.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for token, want := range map[string]string{oldToken: "old", newToken: "new"} {
+		_, kid, err := ring.VerifyWithKeyId(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if kid != want {
+			t.Fatalf("expected token verified by %s, got %s", want, kid)
+		}
+	}
+
+	if err := ring.Retire("old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Verify(ctx, oldToken); err == nil {
+		t.Fatal("expected token of retired key to be rejected")
+	}
+	if ids := ring.KeyIds(); len(ids) != 2 || ids[0] != "new" || ids[1] != "next" {
+		t.Fatalf("unexpected key ids: %v", ids)
+	}
+}
@@ -0,0 +1,285 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+var (
+	algorithmsRSA = []string{AlgRS256, AlgRS384, AlgRS512, AlgPS256, AlgPS384, AlgPS512}
+)
+
+// signingMethod returns the signing method matching the type of the private key.
+// The returned key is the normalized key accepted by the signing method.
+//
+// Supported keys:
+//   - ed25519.PrivateKey, *ed25519.PrivateKey, *ed25519.KeyPair: EdDSA
+//   - ecdsa.PrivateKey, *ecdsa.PrivateKey, *ecdsa.KeyPair: ES256, ES384 or ES512 depends on the curve
+//   - rsa.PrivateKey, *rsa.PrivateKey, *rsa.KeyPair: RS256 by default, alg can be one of RS256/384/512, PS256/384/512
+//   - crypto.Signer: the algorithm of the signer (Algorithm method) or selected from the type of the public key
+func signingMethod(key any, alg string) (jwt.SigningMethod, any, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return methodEd25519(k, alg)
+	case *ed25519.PrivateKey:
+		if k == nil {
+			return nil, nil, errors.New("private key is nil")
+		}
+		return methodEd25519(*k, alg)
+	case *edKeys.KeyPair:
+		if k == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return methodEd25519(k.PrivateKey, alg)
+	case ecdsa.PrivateKey:
+		return methodECDSA(&k, alg)
+	case *ecdsa.PrivateKey:
+		return methodECDSA(k, alg)
+	case *ecKeys.KeyPair:
+		if k == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return methodECDSA(k.PrivateKey, alg)
+	case rsa.PrivateKey:
+		return methodRSA(&k, alg)
+	case *rsa.PrivateKey:
+		return methodRSA(k, alg)
+	case *rsaKeys.KeyPair:
+		if k == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return methodRSA(k.PrivateKey, alg)
+	case crypto.Signer:
+		// The default algorithm of the key, e.g. keystore.Signer
+		if a, ok := k.(interface{ Algorithm() string }); ok && alg == "" {
+			alg = a.Algorithm()
+		}
+		return methodSigner(k, alg)
+	default:
+		return nil, nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+}
+
+func methodEd25519(key ed25519.PrivateKey, alg string) (jwt.SigningMethod, any, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, nil, errors.New("invalid ed25519 private key")
+	}
+	if alg != "" && alg != AlgEdDSA {
+		return nil, nil, fmt.Errorf("algorithm %s is not supported by ed25519 key", alg)
+	}
+	return jwt.SigningMethodEdDSA, key, nil
+}
+
+func methodECDSA(key *ecdsa.PrivateKey, alg string) (jwt.SigningMethod, any, error) {
+	if key == nil {
+		return nil, nil, errors.New("private key is nil")
+	}
+	method, err := methodOfCurve(key.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	if alg != "" && alg != method.Alg() {
+		return nil, nil, fmt.Errorf("algorithm %s is not supported by ecdsa key (curve %s)", alg, key.Curve.Params().Name)
+	}
+	return method, key, nil
+}
+
+func methodRSA(key *rsa.PrivateKey, alg string) (jwt.SigningMethod, any, error) {
+	if key == nil {
+		return nil, nil, errors.New("private key is nil")
+	}
+	if alg == "" {
+		alg = AlgRS256
+	}
+	if !slices.Contains(algorithmsRSA, alg) {
+		return nil, nil, fmt.Errorf("algorithm %s is not supported by rsa key", alg)
+	}
+	return jwt.GetSigningMethod(alg), key, nil
+}
+
+func methodSigner(signer crypto.Signer, alg string) (jwt.SigningMethod, any, error) {
+	switch pub := signer.Public().(type) {
+	case ed25519.PublicKey:
+		// jwt.SigningMethodEdDSA accepts crypto.Signer as is
+		if alg != "" && alg != AlgEdDSA {
+			return nil, nil, fmt.Errorf("algorithm %s is not supported by ed25519 key", alg)
+		}
+		return jwt.SigningMethodEdDSA, signer, nil
+	case *ecdsa.PublicKey:
+		method, err := methodOfCurve(pub.Curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		if alg != "" && alg != method.Alg() {
+			return nil, nil, fmt.Errorf("algorithm %s is not supported by ecdsa key (curve %s)", alg, pub.Curve.Params().Name)
+		}
+		return &signerMethod{SigningMethod: method, hash: method.Hash, keySize: method.KeySize}, signer, nil
+	case *rsa.PublicKey:
+		if alg == "" {
+			alg = AlgRS256
+		}
+		if !slices.Contains(algorithmsRSA, alg) {
+			return nil, nil, fmt.Errorf("algorithm %s is not supported by rsa key", alg)
+		}
+		switch method := jwt.GetSigningMethod(alg).(type) {
+		case *jwt.SigningMethodRSAPSS:
+			return &signerMethod{SigningMethod: method, hash: method.Hash, pss: true}, signer, nil
+		case *jwt.SigningMethodRSA:
+			return &signerMethod{SigningMethod: method, hash: method.Hash}, signer, nil
+		}
+		return nil, nil, fmt.Errorf("algorithm %s is not supported by rsa key", alg)
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key type of signer: %T", pub)
+	}
+}
+
+func methodOfCurve(curve elliptic.Curve) (*jwt.SigningMethodECDSA, error) {
+	switch curve {
+	case elliptic.P256():
+		return jwt.SigningMethodES256, nil
+	case elliptic.P384():
+		return jwt.SigningMethodES384, nil
+	case elliptic.P521():
+		return jwt.SigningMethodES512, nil
+	default:
+		return nil, fmt.Errorf("unsupported ecdsa curve: %s", curve.Params().Name)
+	}
+}
+
+// signerMethod signs with an opaque crypto.Signer (e.g. a key held by a KMS or HSM).
+// Verification is delegated to the embedded standard signing method.
+type signerMethod struct {
+	jwt.SigningMethod
+	hash    crypto.Hash
+	pss     bool
+	keySize int // size of r and s in bytes, ECDSA only
+}
+
+func (m *signerMethod) Sign(signingString string, key any) ([]byte, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s sign expects crypto.Signer: %w", m.Alg(), jwt.ErrInvalidKeyType)
+	}
+	if !m.hash.Available() {
+		return nil, jwt.ErrHashUnavailable
+	}
+	h := m.hash.New()
+	h.Write([]byte(signingString))
+	digest := h.Sum(nil)
+
+	var signerOpts crypto.SignerOpts = m.hash
+	if m.pss {
+		signerOpts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: m.hash}
+	}
+	sig, err := signer.Sign(rand.Reader, digest, signerOpts)
+	if err != nil {
+		return nil, err
+	}
+	if m.keySize == 0 {
+		return sig, nil
+	}
+
+	// crypto.Signer returns an ASN.1 DER signature for ECDSA, JWS expects r||s
+	var esig struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &esig); err != nil {
+		return nil, fmt.Errorf("failed to parse ecdsa signature: %w", err)
+	}
+	out := make([]byte, 2*m.keySize)
+	esig.R.FillBytes(out[:m.keySize])
+	esig.S.FillBytes(out[m.keySize:])
+	return out, nil
+}
+
+// verificationKey returns the normalized public key and the algorithms it is allowed to verify.
+// Private keys are accepted too, only their public part is used.
+func verificationKey(key any) (crypto.PublicKey, []string, error) {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid ed25519 public key")
+		}
+		return k, []string{AlgEdDSA}, nil
+	case *ed25519.PublicKey:
+		if k == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		return verificationKey(*k)
+	case *edKeys.KeyPair:
+		if k == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return verificationKey(k.PublicKey)
+	case *edKeys.PublicKey:
+		if k == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		return verificationKey(k.PublicKey)
+	case ecdsa.PublicKey:
+		return verificationKey(&k)
+	case *ecdsa.PublicKey:
+		if k == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		method, err := methodOfCurve(k.Curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		return k, []string{method.Alg()}, nil
+	case *ecKeys.KeyPair:
+		if k == nil || k.PrivateKey == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return verificationKey(&k.PrivateKey.PublicKey)
+	case *ecKeys.PublicKey:
+		if k == nil || k.PublicKey == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		return verificationKey(k.PublicKey)
+	case rsa.PublicKey:
+		return verificationKey(&k)
+	case *rsa.PublicKey:
+		if k == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		return k, slices.Clone(algorithmsRSA), nil
+	case *rsaKeys.KeyPair:
+		if k == nil || k.PrivateKey == nil {
+			return nil, nil, errors.New("key pair is nil")
+		}
+		return verificationKey(&k.PrivateKey.PublicKey)
+	case *rsaKeys.PublicKey:
+		if k == nil || k.PublicKey == nil {
+			return nil, nil, errors.New("public key is nil")
+		}
+		return verificationKey(k.PublicKey)
+	case crypto.Signer:
+		return verificationKey(k.Public())
+	default:
+		return nil, nil, fmt.Errorf("unsupported public key type: %T", key)
+	}
+}
+
+// keyfunc returns a jwt.Keyfunc which only accepts tokens signed with one of the given algorithms.
+func keyfunc(pub crypto.PublicKey, algorithms []string) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		if slices.Contains(algorithms, t.Method.Alg()) {
+			return pub, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+}
@@ -3,6 +3,7 @@ package jwt
 import (
 	"context"
 	"encoding/json"
+	"slices"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -12,6 +13,29 @@ import (
 
 var (
 	SigningMethodEdDSA = jwt.SigningMethodEdDSA
+	SigningMethodES256 = jwt.SigningMethodES256
+	SigningMethodES384 = jwt.SigningMethodES384
+	SigningMethodES512 = jwt.SigningMethodES512
+	SigningMethodRS256 = jwt.SigningMethodRS256
+	SigningMethodRS384 = jwt.SigningMethodRS384
+	SigningMethodRS512 = jwt.SigningMethodRS512
+	SigningMethodPS256 = jwt.SigningMethodPS256
+	SigningMethodPS384 = jwt.SigningMethodPS384
+	SigningMethodPS512 = jwt.SigningMethodPS512
+)
+
+// Algorithm names ("alg" header) supported by SignWithClaims and ParseClaims.
+const (
+	AlgEdDSA = "EdDSA"
+	AlgES256 = "ES256"
+	AlgES384 = "ES384"
+	AlgES512 = "ES512"
+	AlgRS256 = "RS256"
+	AlgRS384 = "RS384"
+	AlgRS512 = "RS512"
+	AlgPS256 = "PS256"
+	AlgPS384 = "PS384"
+	AlgPS512 = "PS512"
 )
 
 // MapClaims defines the custom JWT claims structure.
@@ -28,7 +52,10 @@ var (
 //		  "exp": 1753416963,
 //		  "userId":"6883664f484674420f55c16b",
 //		  "sessionId": "0cf835de-5c39-481d-a371-94884ba91fcd",
-//		  "protobufDataHash":"37a8ddae362e58fbaa3c75f0f201cdd6aa209280cbeb682db29f6d5909595971"
+//		  "protobufDataHash":"37a8ddae362e58fbaa3c75f0f201cdd6aa209280cbeb682db29f6d5909595971",
+//		  "tenantId": "bankaool",
+//		  "roles": ["operator"],
+//		  "scopes": ["payment:read", "payment:write"]
 //		}
 //
 // Fields:
@@ -42,13 +69,31 @@ var (
 //   - SessionId: A custom field representing the session ID.
 //   - UserId: A custom field representing the user ID.
 //   - ProtobufDataHash: A custom field representing the hash of the protobuf data.
+//   - TenantId: A custom field representing the tenant of the user.
+//   - Roles: A custom field representing the roles of the user.
+//   - Scopes: A custom field representing the permissions granted to the token.
+//   - Confirmation: The key the token is bound to ("cnf" claim), see DPoPVerifier.
 //   - Payload: A custom field that can hold any additional payload data.
 type MapClaims struct {
 	jwt.RegisteredClaims
-	SessionId        string `json:"sessionId"`
-	UserId           string `json:"userId"`
-	ProtobufDataHash string `json:"protobufDataHash,omitempty"`
-	Payload          any    `json:"payload,omitempty"`
+	SessionId        string        `json:"sessionId"`
+	UserId           string        `json:"userId"`
+	ProtobufDataHash string        `json:"protobufDataHash,omitempty"`
+	TenantId         string        `json:"tenantId,omitempty"`
+	Roles            []string      `json:"roles,omitempty"`
+	Scopes           []string      `json:"scopes,omitempty"`
+	Confirmation     *Confirmation `json:"cnf,omitempty"`
+	Payload          any           `json:"payload,omitempty"`
+}
+
+// HasRole reports whether the claims contain the given role.
+func (claims *MapClaims) HasRole(role string) bool {
+	return slices.Contains(claims.Roles, role)
+}
+
+// HasScope reports whether the claims contain the given scope.
+func (claims *MapClaims) HasScope(scope string) bool {
+	return slices.Contains(claims.Scopes, scope)
 }
 
 // ParsePayload parses the JWT claims payload into the given struct.
@@ -89,7 +134,11 @@ func (claims *MapClaims) ParseMessage(v proto.Message) error {
 //		return ctx
 //	}
 func (claims *MapClaims) ApplyContext(ctx context.Context) context.Context {
+	ctx = setClaimsToContext(ctx, claims)
 	ctx = setSessionIdToContext(ctx, claims.SessionId)
 	ctx = setUserIdToContext(ctx, claims.UserId)
+	ctx = setTenantIdToContext(ctx, claims.TenantId)
+	ctx = setRolesToContext(ctx, claims.Roles)
+	ctx = setScopesToContext(ctx, claims.Scopes)
 	return ctx
 }
@@ -0,0 +1,154 @@
+// Package mongostore implements the stores of package jwt with MongoDB.
+package mongostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/mongodb"
+)
+
+const (
+	// DefaultRevocationCollection is the collection used when none is given
+	DefaultRevocationCollection = "jwt_revocations"
+
+	kindToken   = "jti"
+	kindSession = "session"
+	kindUser    = "user"
+)
+
+// revocation is the document stored for each revoked token, session or user.
+//
+// Example:
+//
+//	{
+//		"_id": "session:0cf835de-5c39-481d-a371-94884ba91fcd",
+//		"kind": "session",
+//		"value": "0cf835de-5c39-481d-a371-94884ba91fcd",
+//		"revokedAt": ISODate("2026-10-17T08:00:00Z"),
+//		"expiresAt": ISODate("2026-10-18T08:00:00Z")
+//	}
+type revocation struct {
+	ID        string    `bson:"_id"`
+	Kind      string    `bson:"kind"`
+	Value     string    `bson:"value"`
+	RevokedAt time.Time `bson:"revokedAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// RevocationStore implements jwt.RevocationStore with a MongoDB collection.
+// Expired entries are removed by a TTL index on "expiresAt".
+type RevocationStore struct {
+	conn       *mongodb.Connection
+	collection string
+}
+
+var _ jwt.RevocationStore = (*RevocationStore)(nil)
+
+// NewRevocationStore creates the store and ensures the TTL index of the collection.
+// The collection defaults to DefaultRevocationCollection when empty.
+func NewRevocationStore(ctx context.Context, conn *mongodb.Connection, collection string) (*RevocationStore, error) {
+	if conn == nil {
+		return nil, errors.New("mongodb connection is nil")
+	}
+	if collection == "" {
+		collection = DefaultRevocationCollection
+	}
+	s := &RevocationStore{conn: conn, collection: collection}
+
+	err := conn.Write(ctx, func(db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TTL index: %w", err)
+	}
+	return s, nil
+}
+
+func (s *RevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.revoke(ctx, kindToken, jti, expiresAt)
+}
+
+func (s *RevocationStore) RevokeSession(ctx context.Context, sessionId string, expiresAt time.Time) error {
+	return s.revoke(ctx, kindSession, sessionId, expiresAt)
+}
+
+func (s *RevocationStore) RevokeUser(ctx context.Context, userId string, expiresAt time.Time) error {
+	return s.revoke(ctx, kindUser, userId, expiresAt)
+}
+
+func (s *RevocationStore) revoke(ctx context.Context, kind, value string, expiresAt time.Time) error {
+	if value == "" {
+		return errors.New("revocation key is empty")
+	}
+	return s.conn.Write(ctx, func(db *mongo.Database) error {
+		_, err := db.Collection(s.collection).UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: kind + ":" + value}},
+			bson.D{
+				{Key: "$set", Value: bson.D{
+					{Key: "kind", Value: kind},
+					{Key: "value", Value: value},
+					{Key: "revokedAt", Value: time.Now()},
+				}},
+				// Never shorten an existing revocation
+				{Key: "$max", Value: bson.D{{Key: "expiresAt", Value: expiresAt}}},
+			},
+			options.UpdateOne().SetUpsert(true))
+		return err
+	})
+}
+
+func (s *RevocationStore) IsRevoked(ctx context.Context, claims *jwt.MapClaims) (bool, error) {
+	var ids []string
+	if claims.ID != "" {
+		ids = append(ids, kindToken+":"+claims.ID)
+	}
+	if claims.SessionId != "" {
+		ids = append(ids, kindSession+":"+claims.SessionId)
+	}
+	if claims.UserId != "" {
+		ids = append(ids, kindUser+":"+claims.UserId)
+	}
+	if len(ids) == 0 {
+		return false, nil
+	}
+
+	var docs []revocation
+	// Read from the primary, a revocation must be effective immediately
+	err := s.conn.ReadPrimary(ctx, func(db *mongo.Database) error {
+		cursor, err := db.Collection(s.collection).Find(ctx, bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+			// The TTL monitor runs once per minute, skip the expired entries not removed yet
+			{Key: "expiresAt", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+		})
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &docs)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, doc := range docs {
+		if doc.Kind == kindToken {
+			return true, nil
+		}
+		// Sessions and users revoke the tokens issued before the revocation only
+		if claims.IssuedAt == nil || !claims.IssuedAt.After(doc.RevokedAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
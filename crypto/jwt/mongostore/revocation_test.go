@@ -0,0 +1,128 @@
+package mongostore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/mongodb"
+)
+
+// envMongoURI is the URI of the MongoDB used by the tests, they are skipped when it is not set,
+// e.g. MONGODB_URI="mongodb://localhost:27017/testDB" go test ./crypto/jwt/mongostore
+const envMongoURI = "MONGODB_URI"
+
+// newTestStore creates a store on a collection of its own, dropped at the end of the test.
+func newTestStore(t *testing.T) (*RevocationStore, *mongodb.Connection) {
+	t.Helper()
+	uri := os.Getenv(envMongoURI)
+	if uri == "" {
+		t.Skipf("%s is not set", envMongoURI)
+	}
+	ctx := context.Background()
+	conn, err := mongodb.NewConnection(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := "jwt_revocations_test_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	t.Cleanup(func() {
+		_ = conn.Write(ctx, func(db *mongo.Database) error {
+			return db.Collection(collection).Drop(ctx)
+		})
+		_ = conn.Close()
+	})
+
+	store, err := NewRevocationStore(ctx, conn, collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, conn
+}
+
+func TestRevocationStoreIndex(t *testing.T) {
+	store, conn := newTestStore(t)
+	ctx := context.Background()
+
+	var indexes []bson.M
+	err := conn.ReadPrimary(ctx, func(db *mongo.Database) error {
+		cursor, err := db.Collection(store.collection).Indexes().List(ctx)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &indexes)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range indexes {
+		if index["name"] != "expiresAt_ttl" {
+			continue
+		}
+		// The server may return the number as int32, int64 or double
+		if ttl := fmt.Sprint(index["expireAfterSeconds"]); ttl != "0" {
+			t.Fatalf("unexpected expireAfterSeconds: %v", index["expireAfterSeconds"])
+		}
+		return
+	}
+	t.Fatalf("TTL index not found in %v", indexes)
+}
+
+func TestRevocationStore(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+	claims := func(jti, sessionId string, issuedAt time.Time) *jwt.MapClaims {
+		return &jwt.MapClaims{
+			RegisteredClaims: jwtv5.RegisteredClaims{ID: jti, IssuedAt: jwtv5.NewNumericDate(issuedAt)},
+			SessionId:        sessionId,
+			UserId:           "u-1",
+		}
+	}
+
+	if revoked, err := store.IsRevoked(ctx, claims("jti-1", "s-1", now)); err != nil || revoked {
+		t.Fatalf("expected not revoked, got %v %v", revoked, err)
+	}
+
+	// Token
+	if err := store.RevokeToken(ctx, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := store.IsRevoked(ctx, claims("jti-1", "s-1", now)); err != nil || !revoked {
+		t.Fatalf("expected the token to be revoked, got %v %v", revoked, err)
+	}
+
+	// Session: the tokens issued before the revocation only
+	if err := store.RevokeSession(ctx, "s-2", now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := store.IsRevoked(ctx, claims("jti-2", "s-2", now.Add(-time.Minute))); err != nil || !revoked {
+		t.Fatalf("expected the session to be revoked, got %v %v", revoked, err)
+	}
+	if revoked, err := store.IsRevoked(ctx, claims("jti-3", "s-2", now.Add(time.Minute))); err != nil || revoked {
+		t.Fatalf("expected a token issued after the revocation to be valid, got %v %v", revoked, err)
+	}
+
+	// Expired entries are ignored before the TTL monitor removes them
+	if err := store.RevokeToken(ctx, "jti-4", now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := store.IsRevoked(ctx, claims("jti-4", "s-4", now)); err != nil || revoked {
+		t.Fatalf("expected an expired revocation to be ignored, got %v %v", revoked, err)
+	}
+
+	// A revocation is never shortened
+	if err := store.RevokeToken(ctx, "jti-1", now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := store.IsRevoked(ctx, claims("jti-1", "s-1", now)); err != nil || !revoked {
+		t.Fatalf("expected the token to stay revoked, got %v %v", revoked, err)
+	}
+}
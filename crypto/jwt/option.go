@@ -1,8 +1,12 @@
 package jwt
 
 import (
+	"fmt"
+	"net/http"
+	"slices"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
@@ -10,12 +14,32 @@ func NewOption() *Option {
 	return &Option{
 		sessionId: uuid.NewString(),
 		liveTime:  90 * time.Second,
+		issuer:    issue,
+		subject:   subject,
+		audience:  []string{"LOGIN"},
 	}
 }
 
 type Option struct {
 	sessionId, userId, protoDataHash string
 	liveTime                         time.Duration
+
+	// Registered claims
+	issuer, subject string
+	audience        []string
+	notBeforeOffset time.Duration
+
+	// Signing algorithm, empty means the default algorithm of the key type
+	algorithm string
+	// Key ID ("kid" header), empty means the RFC 7638 thumbprint of the key
+	keyId string
+
+	// Authorization claims
+	tenantId      string
+	roles, scopes []string
+
+	// RFC 7638 thumbprint of the DPoP key the token is bound to
+	dpopThumbprint string
 }
 
 func (src *Option) SetLiveTime(d time.Duration) *Option {
@@ -48,6 +72,7 @@ func (src *Option) UserId() string {
 	return src.userId
 }
 
+// SetProtobufDataHash binds the token to a request, see ProtobufDataHash.
 func (src *Option) SetProtobufDataHash(hash string) *Option {
 	dst := *src
 	dst.protoDataHash = hash
@@ -57,3 +82,357 @@ func (src *Option) SetProtobufDataHash(hash string) *Option {
 func (src *Option) ProtoDataHash() string {
 	return src.protoDataHash
 }
+
+func (src *Option) SetIssuer(issuer string) *Option {
+	dst := *src
+	dst.issuer = issuer
+	return &dst
+}
+
+func (src *Option) Issuer() string {
+	return src.issuer
+}
+
+func (src *Option) SetSubject(subject string) *Option {
+	dst := *src
+	dst.subject = subject
+	return &dst
+}
+
+func (src *Option) Subject() string {
+	return src.subject
+}
+
+func (src *Option) SetAudience(audience ...string) *Option {
+	dst := *src
+	dst.audience = append([]string(nil), audience...)
+	return &dst
+}
+
+func (src *Option) Audience() []string {
+	return src.audience
+}
+
+// SetNotBeforeOffset shifts the "nbf" claim relative to the issue time.
+// A positive offset makes the token valid only after the offset has elapsed.
+func (src *Option) SetNotBeforeOffset(d time.Duration) *Option {
+	dst := *src
+	dst.notBeforeOffset = d
+	return &dst
+}
+
+func (src *Option) NotBeforeOffset() time.Duration {
+	return src.notBeforeOffset
+}
+
+// SetAlgorithm selects the signing algorithm, e.g. AlgPS256 for a RSA key.
+// It must match the key type, by default the algorithm is picked from the key type.
+func (src *Option) SetAlgorithm(alg string) *Option {
+	dst := *src
+	dst.algorithm = alg
+	return &dst
+}
+
+func (src *Option) Algorithm() string {
+	return src.algorithm
+}
+
+// SetKeyId overrides the "kid" header of the token.
+// By default it is the RFC 7638 thumbprint of the public key, see KeyId.
+func (src *Option) SetKeyId(kid string) *Option {
+	dst := *src
+	dst.keyId = kid
+	return &dst
+}
+
+func (src *Option) KeyId() string {
+	return src.keyId
+}
+
+// SetTenantId sets the "tenantId" claim.
+func (src *Option) SetTenantId(tenantId string) *Option {
+	dst := *src
+	dst.tenantId = tenantId
+	return &dst
+}
+
+func (src *Option) TenantId() string {
+	return src.tenantId
+}
+
+// SetRoles sets the "roles" claim.
+func (src *Option) SetRoles(roles ...string) *Option {
+	dst := *src
+	dst.roles = append([]string(nil), roles...)
+	return &dst
+}
+
+func (src *Option) Roles() []string {
+	return src.roles
+}
+
+// SetScopes sets the "scopes" claim, the permissions granted to the token (e.g. "payment:write").
+func (src *Option) SetScopes(scopes ...string) *Option {
+	dst := *src
+	dst.scopes = append([]string(nil), scopes...)
+	return &dst
+}
+
+func (src *Option) Scopes() []string {
+	return src.scopes
+}
+
+// SetDPoPThumbprint binds the token to a DPoP key ("cnf.jkt" claim), see DPoPVerifier.
+// The token is then accepted only with a DPoP proof signed by this key.
+func (src *Option) SetDPoPThumbprint(jkt string) *Option {
+	dst := *src
+	dst.dpopThumbprint = jkt
+	return &dst
+}
+
+func (src *Option) DPoPThumbprint() string {
+	return src.dpopThumbprint
+}
+
+// MergeOption merges the options as SignWithClaims does, for the packages issuing other token formats.
+func MergeOption(opts ...*Option) *Option {
+	return mergeOption(opts)
+}
+
+// mergeOption merges the given options over the default option.
+// The shortest live time wins, the other fields are taken from the first option.
+func mergeOption(opts []*Option) *Option {
+	opt := NewOption()
+	for i, op := range opts {
+		if op == nil {
+			continue
+		}
+		if op.liveTime > 0 && (i == 0 || op.liveTime < opt.liveTime) {
+			opt = opt.SetLiveTime(op.liveTime)
+		}
+		if i != 0 {
+			continue
+		}
+		if op.sessionId != "" {
+			opt = opt.SetSessionId(op.sessionId)
+		}
+		if op.userId != "" {
+			opt = opt.SetUserId(op.userId)
+		}
+		if op.protoDataHash != "" {
+			opt = opt.SetProtobufDataHash(op.protoDataHash)
+		}
+		if op.issuer != "" {
+			opt = opt.SetIssuer(op.issuer)
+		}
+		if op.subject != "" {
+			opt = opt.SetSubject(op.subject)
+		}
+		if len(op.audience) > 0 {
+			opt = opt.SetAudience(op.audience...)
+		}
+		if op.notBeforeOffset != 0 {
+			opt = opt.SetNotBeforeOffset(op.notBeforeOffset)
+		}
+		if op.algorithm != "" {
+			opt = opt.SetAlgorithm(op.algorithm)
+		}
+		if op.keyId != "" {
+			opt = opt.SetKeyId(op.keyId)
+		}
+		if op.tenantId != "" {
+			opt = opt.SetTenantId(op.tenantId)
+		}
+		if len(op.roles) > 0 {
+			opt = opt.SetRoles(op.roles...)
+		}
+		if len(op.scopes) > 0 {
+			opt = opt.SetScopes(op.scopes...)
+		}
+		if op.dpopThumbprint != "" {
+			opt = opt.SetDPoPThumbprint(op.dpopThumbprint)
+		}
+	}
+	return opt
+}
+
+func NewParseOption() *ParseOption {
+	return &ParseOption{}
+}
+
+// ParseOption defines the validation rules applied by ParseClaims.
+type ParseOption struct {
+	issuer   string
+	audience []string
+	leeway   time.Duration
+
+	// Allowlist of signing algorithms, empty means every algorithm of the key type
+	algorithms []string
+	// Revoked tokens, nil means revocation is not checked
+	revocationStore RevocationStore
+}
+
+// SetIssuer requires the "iss" claim to be equal to the given issuer.
+func (src *ParseOption) SetIssuer(issuer string) *ParseOption {
+	dst := *src
+	dst.issuer = issuer
+	return &dst
+}
+
+func (src *ParseOption) Issuer() string {
+	return src.issuer
+}
+
+// SetAudience requires the "aud" claim to contain at least one of the given audiences.
+func (src *ParseOption) SetAudience(audience ...string) *ParseOption {
+	dst := *src
+	dst.audience = append([]string(nil), audience...)
+	return &dst
+}
+
+func (src *ParseOption) Audience() []string {
+	return src.audience
+}
+
+// SetLeeway sets the clock skew tolerated when validating "exp", "nbf" and "iat".
+func (src *ParseOption) SetLeeway(d time.Duration) *ParseOption {
+	dst := *src
+	dst.leeway = d
+	return &dst
+}
+
+func (src *ParseOption) Leeway() time.Duration {
+	return src.leeway
+}
+
+// SetAlgorithms restricts the accepted signing algorithms ("alg" header).
+// Algorithms which do not match the verification key type are always rejected.
+func (src *ParseOption) SetAlgorithms(algorithms ...string) *ParseOption {
+	dst := *src
+	dst.algorithms = append([]string(nil), algorithms...)
+	return &dst
+}
+
+func (src *ParseOption) Algorithms() []string {
+	return src.algorithms
+}
+
+// SetRevocationStore rejects the tokens revoked in the given store with ErrTokenRevoked.
+func (src *ParseOption) SetRevocationStore(store RevocationStore) *ParseOption {
+	dst := *src
+	dst.revocationStore = store
+	return &dst
+}
+
+func (src *ParseOption) RevocationStore() RevocationStore {
+	return src.revocationStore
+}
+
+// MergeParseOption merges the parse options as ParseClaims does, for the packages parsing other token formats.
+func MergeParseOption(opts ...*ParseOption) *ParseOption {
+	return mergeParseOption(opts)
+}
+
+// mergeParseOption merges the given parse options, the first non-empty value wins.
+func mergeParseOption(opts []*ParseOption) *ParseOption {
+	opt := NewParseOption()
+	for _, op := range opts {
+		if op == nil {
+			continue
+		}
+		if opt.issuer == "" && op.issuer != "" {
+			opt = opt.SetIssuer(op.issuer)
+		}
+		if len(opt.audience) == 0 && len(op.audience) > 0 {
+			opt = opt.SetAudience(op.audience...)
+		}
+		if opt.leeway == 0 && op.leeway > 0 {
+			opt = opt.SetLeeway(op.leeway)
+		}
+		if len(opt.algorithms) == 0 && len(op.algorithms) > 0 {
+			opt = opt.SetAlgorithms(op.algorithms...)
+		}
+		if opt.revocationStore == nil && op.revocationStore != nil {
+			opt = opt.SetRevocationStore(op.revocationStore)
+		}
+	}
+	return opt
+}
+
+// allowedAlgorithms intersects the algorithms of the verification key with the allowlist.
+func (src *ParseOption) allowedAlgorithms(ofKey []string) ([]string, error) {
+	allowed := ofKey
+	if len(src.algorithms) > 0 {
+		allowed = slices.DeleteFunc(slices.Clone(ofKey), func(alg string) bool {
+			return !slices.Contains(src.algorithms, alg)
+		})
+	}
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("none of the algorithms %v is allowed for the verification key", ofKey)
+	}
+	return allowed, nil
+}
+
+// parserOptions converts the parse option to the options of jwt.Parser.
+func (src *ParseOption) parserOptions() []jwt.ParserOption {
+	var opts []jwt.ParserOption
+	if src.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(src.issuer))
+	}
+	if len(src.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(src.audience...))
+	}
+	if src.leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(src.leeway))
+	}
+	return opts
+}
+
+func NewJWKSOption() *JWKSOption {
+	return &JWKSOption{
+		refreshInterval:    15 * time.Minute,
+		minRefreshInterval: 30 * time.Second,
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// JWKSOption defines how JWKSVerifier loads and caches the JSON Web Key Set.
+type JWKSOption struct {
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	httpClient         *http.Client
+}
+
+// SetRefreshInterval sets how long the cached key set is used before it is loaded again.
+func (src *JWKSOption) SetRefreshInterval(d time.Duration) *JWKSOption {
+	dst := *src
+	dst.refreshInterval = d
+	return &dst
+}
+
+func (src *JWKSOption) RefreshInterval() time.Duration {
+	return src.refreshInterval
+}
+
+// SetMinRefreshInterval sets the minimum delay between two loads triggered by an unknown "kid".
+// It protects the issuer against tokens crafted with random key IDs.
+func (src *JWKSOption) SetMinRefreshInterval(d time.Duration) *JWKSOption {
+	dst := *src
+	dst.minRefreshInterval = d
+	return &dst
+}
+
+func (src *JWKSOption) MinRefreshInterval() time.Duration {
+	return src.minRefreshInterval
+}
+
+// SetHTTPClient sets the HTTP client used to download the key set.
+func (src *JWKSOption) SetHTTPClient(client *http.Client) *JWKSOption {
+	dst := *src
+	dst.httpClient = client
+	return &dst
+}
+
+func (src *JWKSOption) HTTPClient() *http.Client {
+	return src.httpClient
+}
@@ -0,0 +1,356 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-devkit/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// refreshTokenSize is the size in bytes of the random refresh token
+	refreshTokenSize = 32
+)
+
+var (
+	// ErrRefreshTokenNotFound is returned by RefreshStore.Use for an unknown token.
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenInvalid is returned when the refresh token is unknown or its session has been invalidated.
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	// ErrRefreshTokenExpired is returned when the refresh token has expired.
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	// ErrRefreshTokenReused is returned when a refresh token is used twice, the whole session is invalidated.
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// TokenPair is an access token and its refresh token, both bound to the same SessionId.
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	RefreshToken     string    `json:"refreshToken"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// RefreshRecord is what a RefreshStore keeps for each refresh token.
+// The refresh token itself is never stored, only its SHA-256 hash.
+//
+// All the refresh tokens of a session form a family: rotating a token adds a new record to the family,
+// and the reuse of a rotated token invalidates the whole family.
+type RefreshRecord struct {
+	TokenHash string          `json:"tokenHash"`
+	SessionId string          `json:"sessionId"`
+	UserId    string          `json:"userId"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	IssuedAt  time.Time       `json:"issuedAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	// AccessExpiresAt is the expiry of the access token issued with the refresh token
+	AccessExpiresAt time.Time `json:"accessExpiresAt"`
+
+	// Claims of the session, reapplied to the access tokens of every rotation
+	Audience       []string `json:"audience,omitempty"`
+	TenantId       string   `json:"tenantId,omitempty"`
+	Roles          []string `json:"roles,omitempty"`
+	Scopes         []string `json:"scopes,omitempty"`
+	DPoPThumbprint string   `json:"dpopThumbprint,omitempty"`
+	// UsedAt is zero until the token is rotated
+	UsedAt time.Time `json:"usedAt,omitzero"`
+}
+
+// RefreshStore keeps the refresh token records.
+type RefreshStore interface {
+	// Save stores the record of a new refresh token.
+	Save(ctx context.Context, record *RefreshRecord) error
+	// Use marks the token as used at the given time and returns the record as it was before,
+	// so a non-zero UsedAt means the token is reused. It must be atomic.
+	// ErrRefreshTokenNotFound is returned for an unknown token.
+	Use(ctx context.Context, tokenHash string, usedAt time.Time) (*RefreshRecord, error)
+	// RevokeFamily removes all the refresh tokens of the session,
+	// and returns the latest AccessExpiresAt of their records (zero when the session has no token).
+	RevokeFamily(ctx context.Context, sessionId string) (time.Time, error)
+}
+
+// RefreshIssuer issues access/refresh token pairs and rotates the refresh token on every use.
+//
+// Example:
+//
+//	issuer := jwt.NewRefreshIssuer(keyPair, jwt.NewMemoryRefreshStore()).
+//		SetRefreshLiveTime(7 * 24 * time.Hour).
+//		SetRevocationStore(revocations)
+//
+//	pair, err := issuer.Issue(ctx, payload, jwt.NewOption().SetUserId(userId))
+//	// ... later, when the access token has expired
+//	pair, err = issuer.Refresh(ctx, pair.RefreshToken)
+type RefreshIssuer struct {
+	key             any
+	store           RefreshStore
+	revocationStore RevocationStore
+	refreshLiveTime time.Duration
+}
+
+// NewRefreshIssuer creates a RefreshIssuer signing the access tokens with the given key,
+// any key accepted by SignWithClaims or a *Keyring.
+func NewRefreshIssuer(key any, store RefreshStore) *RefreshIssuer {
+	return &RefreshIssuer{
+		key:             key,
+		store:           store,
+		refreshLiveTime: 24 * time.Hour,
+	}
+}
+
+// SetRefreshLiveTime sets the live time of the refresh tokens, each rotation starts a new live time.
+func (src *RefreshIssuer) SetRefreshLiveTime(d time.Duration) *RefreshIssuer {
+	dst := *src
+	dst.refreshLiveTime = d
+	return &dst
+}
+
+func (src *RefreshIssuer) RefreshLiveTime() time.Duration {
+	return src.refreshLiveTime
+}
+
+// SetRevocationStore revokes the access tokens of the session too when the session is invalidated.
+func (src *RefreshIssuer) SetRevocationStore(store RevocationStore) *RefreshIssuer {
+	dst := *src
+	dst.revocationStore = store
+	return &dst
+}
+
+// Issue issues a new token pair, the access token is signed with the given options.
+func (i *RefreshIssuer) Issue(ctx context.Context, payload any, opts ...*Option) (*TokenPair, error) {
+	var raw json.RawMessage
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode payload: %w", err)
+		}
+		raw = b
+	}
+	return i.issue(ctx, raw, mergeOption(opts))
+}
+
+func (i *RefreshIssuer) issue(ctx context.Context, payload json.RawMessage, opt *Option) (*TokenPair, error) {
+	var (
+		now         = time.Now()
+		accessToken string
+		err         error
+	)
+	// json.RawMessage(nil) would be encoded as null
+	var claimsPayload any
+	if len(payload) > 0 {
+		claimsPayload = payload
+	}
+	if ring, ok := i.key.(*Keyring); ok {
+		accessToken, err = ring.Sign(claimsPayload, opt)
+	} else {
+		accessToken, err = SignWithClaims(i.key, claimsPayload, opt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, tokenHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	record := &RefreshRecord{
+		TokenHash:       tokenHash,
+		SessionId:       opt.SessionId(),
+		UserId:          opt.UserId(),
+		Payload:         payload,
+		IssuedAt:        now,
+		ExpiresAt:       now.Add(i.refreshLiveTime),
+		AccessExpiresAt: now.Add(opt.LiveTime()),
+		Audience:        opt.Audience(),
+		TenantId:        opt.TenantId(),
+		Roles:           opt.Roles(),
+		Scopes:          opt.Scopes(),
+		DPoPThumbprint:  opt.DPoPThumbprint(),
+	}
+	if err := i.store.Save(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        record.AccessExpiresAt,
+		RefreshExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair of the same session.
+// The refresh token can be used once: if it is used again, the whole session is invalidated
+// and ErrRefreshTokenReused is returned.
+//
+// The options of the new access token should be the ones given to Issue (e.g. the live time),
+// the SessionId, UserId, payload, audience, tenant, roles, scopes and DPoP binding are taken from the refresh token.
+// The refresh token of a session bound to a DPoP key is rejected, see RefreshWithDPoP.
+func (i *RefreshIssuer) Refresh(ctx context.Context, refreshToken string, opts ...*Option) (*TokenPair, error) {
+	return i.refresh(ctx, refreshToken, "", opts)
+}
+
+// RefreshWithDPoP exchanges the refresh token of a session bound to a DPoP key (see Option.SetDPoPThumbprint),
+// the DPoP proof of the token request must be signed by the same key (RFC 9449, section 5).
+// Otherwise the refresh token may have been stolen: the whole session is invalidated.
+//
+// Example:
+//
+//	pair, err := issuer.RefreshWithDPoP(ctx, refreshToken, dpop, r.Header.Get("DPoP"),
+//		jwt.DPoPRequest{Method: r.Method, URL: "https://api.example.com/oauth/token"})
+func (i *RefreshIssuer) RefreshWithDPoP(ctx context.Context, refreshToken string, dpop *DPoPVerifier, proof string, req DPoPRequest, opts ...*Option) (*TokenPair, error) {
+	jkt, err := dpop.Verify(ctx, proof, req)
+	if err != nil {
+		return nil, err
+	}
+	return i.refresh(ctx, refreshToken, jkt, opts)
+}
+
+// refresh rotates the refresh token, jkt is the thumbprint of the verified DPoP proof or empty.
+func (i *RefreshIssuer) refresh(ctx context.Context, refreshToken, jkt string, opts []*Option) (*TokenPair, error) {
+	now := time.Now()
+	record, err := i.store.Use(ctx, hashRefreshToken(refreshToken), now)
+	switch {
+	case errors.Is(err, ErrRefreshTokenNotFound):
+		return nil, ErrRefreshTokenInvalid
+	case err != nil:
+		return nil, err
+	}
+
+	if !record.UsedAt.IsZero() {
+		// Reuse detected: the token may have been stolen, invalidate the whole family
+		logger.NewEntry().Warn("Refresh token bị sử dụng lại, thu hồi toàn bộ phiên",
+			zap.String(logger.KeyFunctionName, "RefreshIssuer.Refresh"),
+			zap.String("session_id", record.SessionId),
+			zap.String("user_id", record.UserId),
+			zap.Time("used_at", record.UsedAt))
+		if err := i.Revoke(ctx, record.SessionId); err != nil {
+			return nil, fmt.Errorf("%w: failed to revoke session: %v", ErrRefreshTokenReused, err)
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !now.Before(record.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+	if record.DPoPThumbprint != "" && subtle.ConstantTimeCompare([]byte(record.DPoPThumbprint), []byte(jkt)) != 1 {
+		logger.NewEntry().Warn("Refresh token của phiên DPoP không có proof hợp lệ, thu hồi toàn bộ phiên",
+			zap.String(logger.KeyFunctionName, "RefreshIssuer.Refresh"),
+			zap.String("session_id", record.SessionId),
+			zap.String("user_id", record.UserId))
+		if err := i.Revoke(ctx, record.SessionId); err != nil {
+			return nil, fmt.Errorf("%w: failed to revoke session: %v", ErrDPoPKeyMismatch, err)
+		}
+		return nil, fmt.Errorf("%w: refresh token is bound to a DPoP key", ErrDPoPKeyMismatch)
+	}
+
+	opt := mergeOption(opts).
+		SetSessionId(record.SessionId).
+		SetUserId(record.UserId).
+		SetAudience(record.Audience...).
+		SetTenantId(record.TenantId).
+		SetRoles(record.Roles...).
+		SetScopes(record.Scopes...).
+		SetDPoPThumbprint(record.DPoPThumbprint)
+	return i.issue(ctx, record.Payload, opt)
+}
+
+// Revoke invalidates the session: its refresh tokens are removed and,
+// with a revocation store, its access tokens are revoked too.
+func (i *RefreshIssuer) Revoke(ctx context.Context, sessionId string) error {
+	accessExpiresAt, err := i.store.RevokeFamily(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	// The access tokens of the session are revoked until the last of them expires
+	if i.revocationStore != nil && time.Now().Before(accessExpiresAt) {
+		return i.revocationStore.RevokeSession(ctx, sessionId, accessExpiresAt)
+	}
+	return nil
+}
+
+func newRefreshToken() (token, tokenHash string, err error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryRefreshStore is an in-memory RefreshStore, for tests and single instance deployments.
+type MemoryRefreshStore struct {
+	mu       sync.Mutex
+	records  map[string]RefreshRecord
+	families map[string][]string
+}
+
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{
+		records:  make(map[string]RefreshRecord),
+		families: make(map[string][]string),
+	}
+}
+
+func (s *MemoryRefreshStore) Save(ctx context.Context, record *RefreshRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[record.TokenHash]; ok {
+		return errors.New("refresh token already exists")
+	}
+	// Drop the expired tokens of the family, they can not be reused anyway,
+	// once their access token has expired too
+	family := s.families[record.SessionId][:0]
+	for _, tokenHash := range s.families[record.SessionId] {
+		if prev, ok := s.records[tokenHash]; ok && (time.Now().Before(prev.ExpiresAt) || time.Now().Before(prev.AccessExpiresAt)) {
+			family = append(family, tokenHash)
+		} else {
+			delete(s.records, tokenHash)
+		}
+	}
+	s.records[record.TokenHash] = *record
+	s.families[record.SessionId] = append(family, record.TokenHash)
+	return nil
+}
+
+func (s *MemoryRefreshStore) Use(ctx context.Context, tokenHash string, usedAt time.Time) (*RefreshRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[tokenHash]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+	before := record
+	if record.UsedAt.IsZero() {
+		record.UsedAt = usedAt
+		s.records[tokenHash] = record
+	}
+	return &before, nil
+}
+
+func (s *MemoryRefreshStore) RevokeFamily(ctx context.Context, sessionId string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var accessExpiresAt time.Time
+	for _, tokenHash := range s.families[sessionId] {
+		if record, ok := s.records[tokenHash]; ok && record.AccessExpiresAt.After(accessExpiresAt) {
+			accessExpiresAt = record.AccessExpiresAt
+		}
+		delete(s.records, tokenHash)
+	}
+	delete(s.families, sessionId)
+	return accessExpiresAt, nil
+}
@@ -0,0 +1,150 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrTokenRevoked is returned when the token, its session or its user has been revoked.
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
+// RevocationStore keeps the revoked tokens until they expire.
+//
+// A token is revoked when:
+//   - its ID (jti) has been revoked, or
+//   - its SessionId has been revoked after the token was issued, or
+//   - its UserId has been revoked after the token was issued.
+//
+// The expiresAt argument is the time the revocation entry can be forgotten,
+// i.e. the expiration time of the token, or now plus the longest live time for a session or user.
+type RevocationStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionId string, expiresAt time.Time) error
+	RevokeUser(ctx context.Context, userId string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims *MapClaims) (bool, error)
+}
+
+// RevokeClaims revokes the token of the given claims until its expiration time.
+func RevokeClaims(ctx context.Context, store RevocationStore, claims *MapClaims) error {
+	if claims.ID == "" {
+		return errors.New("token has no ID (jti)")
+	}
+	expiresAt := time.Now().Add(NewOption().LiveTime())
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return store.RevokeToken(ctx, claims.ID, expiresAt)
+}
+
+// checkRevocation returns ErrTokenRevoked if the claims have been revoked.
+// Errors of the store are returned as is, the token is never accepted when the store is unavailable.
+func checkRevocation(ctx context.Context, store RevocationStore, claims *MapClaims) error {
+	if store == nil {
+		return nil
+	}
+	revoked, err := store.IsRevoked(ctx, claims)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// issuedAtOrBefore reports whether the token was issued at or before t.
+// A token without "iat" is considered issued before any revocation.
+func issuedAtOrBefore(claims *MapClaims, t time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return !claims.IssuedAt.After(t)
+}
+
+type revocationEntry struct {
+	revokedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryRevocationStore is an in-memory RevocationStore, entries are dropped once expired.
+// It is suitable for a single instance or for tests, use a shared store (e.g. MongoDB) otherwise.
+type MemoryRevocationStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]revocationEntry
+	sessions map[string]revocationEntry
+	users    map[string]revocationEntry
+	swept    time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens:   make(map[string]revocationEntry),
+		sessions: make(map[string]revocationEntry),
+		users:    make(map[string]revocationEntry),
+		swept:    time.Now(),
+	}
+}
+
+func (s *MemoryRevocationStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.revoke(s.tokens, jti, expiresAt)
+}
+
+func (s *MemoryRevocationStore) RevokeSession(ctx context.Context, sessionId string, expiresAt time.Time) error {
+	return s.revoke(s.sessions, sessionId, expiresAt)
+}
+
+func (s *MemoryRevocationStore) RevokeUser(ctx context.Context, userId string, expiresAt time.Time) error {
+	return s.revoke(s.users, userId, expiresAt)
+}
+
+func (s *MemoryRevocationStore) revoke(m map[string]revocationEntry, key string, expiresAt time.Time) error {
+	if key == "" {
+		return errors.New("revocation key is empty")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if prev, ok := m[key]; ok && prev.expiresAt.After(expiresAt) {
+		expiresAt = prev.expiresAt
+	}
+	m[key] = revocationEntry{revokedAt: now, expiresAt: expiresAt}
+	return nil
+}
+
+// sweep drops the expired entries, at most once per minute.
+func (s *MemoryRevocationStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+	for _, m := range []map[string]revocationEntry{s.tokens, s.sessions, s.users} {
+		for key, entry := range m {
+			if !now.Before(entry.expiresAt) {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, claims *MapClaims) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	if entry, ok := s.tokens[claims.ID]; ok && claims.ID != "" && now.Before(entry.expiresAt) {
+		return true, nil
+	}
+	if entry, ok := s.sessions[claims.SessionId]; ok && claims.SessionId != "" && now.Before(entry.expiresAt) &&
+		issuedAtOrBefore(claims, entry.revokedAt) {
+		return true, nil
+	}
+	if entry, ok := s.users[claims.UserId]; ok && claims.UserId != "" && now.Before(entry.expiresAt) &&
+		issuedAtOrBefore(claims, entry.revokedAt) {
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,280 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-devkit/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxJWKSSize limits the size of a downloaded JSON Web Key Set
+	maxJWKSSize = 1 << 20
+)
+
+// Verifier verifies a signed token and returns its validated claims.
+type Verifier interface {
+	Verify(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error)
+}
+
+// VerifierFunc is an adapter to allow the use of ordinary functions as Verifier.
+type VerifierFunc func(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error)
+
+// Verify calls f(ctx, str, opts...).
+func (f VerifierFunc) Verify(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error) {
+	return f(ctx, str, opts...)
+}
+
+// PublicKeyVerifier returns a Verifier which verifies tokens with ParseClaims and the given public key.
+func PublicKeyVerifier(pub crypto.PublicKey) Verifier {
+	return VerifierFunc(func(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error) {
+		return ParseClaims(pub, str, opts...)
+	})
+}
+
+type verifierKey struct {
+	pub        crypto.PublicKey
+	algorithms []string
+}
+
+// JWKSVerifier verifies tokens with the keys of a JSON Web Key Set loaded from a URL or a file.
+// The key is selected by the "kid" header of the token.
+//
+// The key set is cached and loaded again after JWKSOption.RefreshInterval,
+// or earlier when a token refers to an unknown key ID (at most once per JWKSOption.MinRefreshInterval).
+type JWKSVerifier struct {
+	source string
+	opt    *JWKSOption
+
+	mu        sync.RWMutex
+	keys      map[string]*verifierKey
+	fetchedAt time.Time
+
+	// serializes the loads of the key set
+	fetchMu sync.Mutex
+	// time of the last load, successful or not, guarded by fetchMu
+	attemptedAt time.Time
+}
+
+// NewJWKSVerifier creates a JWKSVerifier and loads the key set once.
+// The source is either a "http://" or "https://" URL, or a file path (optionally prefixed by "file://").
+//
+// Example:
+//
+//	verifier, err := jwt.NewJWKSVerifier(ctx, "https://auth.example.com/.well-known/jwks.json")
+//	if err != nil {
+//		return err
+//	}
+//	claims, err := verifier.Verify(ctx, jwtStr, jwt.NewParseOption().SetAudience("PAYMENT"))
+func NewJWKSVerifier(ctx context.Context, source string, opts ...*JWKSOption) (*JWKSVerifier, error) {
+	opt := NewJWKSOption()
+	if len(opts) > 0 && opts[0] != nil {
+		if opts[0].refreshInterval > 0 {
+			opt = opt.SetRefreshInterval(opts[0].refreshInterval)
+		}
+		if opts[0].minRefreshInterval > 0 {
+			opt = opt.SetMinRefreshInterval(opts[0].minRefreshInterval)
+		}
+		if opts[0].httpClient != nil {
+			opt = opt.SetHTTPClient(opts[0].httpClient)
+		}
+	}
+	v := &JWKSVerifier{
+		source: source,
+		opt:    opt,
+	}
+	if err := v.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Refresh loads the key set from the source and replaces the cached keys.
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.refresh(ctx)
+}
+
+// refreshIf loads the key set unless it is no longer needed once fetchMu is acquired,
+// or a load was attempted within MinRefreshInterval. It reports whether the key set was loaded.
+func (v *JWKSVerifier) refreshIf(ctx context.Context, needed func() bool) (bool, error) {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+
+	// A failed load is not retried before MinRefreshInterval either
+	if time.Since(v.attemptedAt) < v.opt.MinRefreshInterval() || !needed() {
+		return false, nil
+	}
+	return true, v.refresh(ctx)
+}
+
+// refresh loads the key set, fetchMu must be held.
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	v.attemptedAt = time.Now()
+	set, err := v.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS from %s: %w", v.source, err)
+	}
+
+	keys := make(map[string]*verifierKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// Keys intended for encryption only are not used to verify signatures
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			logger.NewEntry().Warn("Bỏ qua JWK không hợp lệ",
+				zap.String("kid", jwk.Kid), zap.String(logger.KeyError, err.Error()))
+			continue
+		}
+		pub, algorithms, err := verificationKey(key)
+		if err != nil {
+			continue
+		}
+		if jwk.Alg != "" {
+			if !slices.Contains(algorithms, jwk.Alg) {
+				continue
+			}
+			algorithms = []string{jwk.Alg}
+		}
+		kid := jwk.Kid
+		if kid == "" {
+			if kid, err = jwk.Thumbprint(); err != nil {
+				continue
+			}
+		}
+		keys[kid] = &verifierKey{pub: pub, algorithms: algorithms}
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWKSVerifier) load(ctx context.Context) (*JSONWebKeySet, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if strings.HasPrefix(v.source, "http://") || strings.HasPrefix(v.source, "https://") {
+		raw, err = v.download(ctx)
+	} else {
+		raw, err = os.ReadFile(strings.TrimPrefix(v.source, "file://"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var set JSONWebKeySet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	return &set, nil
+}
+
+func (v *JWKSVerifier) download(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/jwk-set+json, application/json")
+	resp, err := v.opt.HTTPClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// lookup returns the key with the given key ID, the key set is loaded again if needed.
+func (v *JWKSVerifier) lookup(ctx context.Context, kid string) (*verifierKey, error) {
+	stale := func() bool {
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+		return time.Since(v.fetchedAt) > v.opt.RefreshInterval()
+	}
+	if stale() {
+		if _, err := v.refreshIf(ctx, stale); err != nil {
+			// Keep using the cached keys, the issuer may be temporarily unavailable
+			logger.NewEntry().Warn("Không thể tải lại JWKS", zap.String(logger.KeyError, err.Error()))
+		}
+	}
+	if key, ok := v.find(kid); ok {
+		return key, nil
+	}
+
+	// Unknown key ID: the key set is loaded again unless another lookup has just loaded it
+	unknown := func() bool {
+		_, ok := v.find(kid)
+		return !ok
+	}
+	if _, err := v.refreshIf(ctx, unknown); err != nil {
+		return nil, err
+	}
+	if key, ok := v.find(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID: %q", kid)
+}
+
+func (v *JWKSVerifier) find(kid string) (*verifierKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	// A token without key ID is accepted only when there is no ambiguity
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// Verify parses and validates the token with the key selected by its "kid" header.
+func (v *JWKSVerifier) Verify(ctx context.Context, str string, opts ...*ParseOption) (*MapClaims, error) {
+	opt := mergeParseOption(opts)
+	return parseClaims(ctx, "JWKSVerifier.Verify", str, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header[headerKeyId].(string)
+		key, err := v.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		algorithms, err := opt.allowedAlgorithms(key.algorithms)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(algorithms, t.Method.Alg()) {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return key.pub, nil
+	}, opt)
+}
+
+// KeyIds returns the IDs of the cached keys.
+func (v *JWKSVerifier) KeyIds() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	ids := make([]string, 0, len(v.keys))
+	for kid := range v.keys {
+		ids = append(ids, kid)
+	}
+	slices.Sort(ids)
+	return ids
+}
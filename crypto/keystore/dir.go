@@ -0,0 +1,294 @@
+package keystore
+
+import (
+	"context"
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+const (
+	// ManifestFile is the name of the manifest in the keystore directory
+	ManifestFile = "manifest.json"
+)
+
+// manifest is the content of the manifest file.
+type manifest struct {
+	Keys []manifestKey `json:"keys"`
+}
+
+type manifestKey struct {
+	KeyInfo
+	File string `json:"file"`
+}
+
+// DirKeystore is a Keystore of PEM files in a directory, the local stand-in of a cloud KMS.
+// The keys are encrypted with the passphrase (see package pkcs8) unless it is empty.
+//
+// The directory contains the manifest, listing the keys and their files:
+//
+//	{
+//		"keys": [
+//			{
+//				"id": "ZhD6rKVgQ3ZoHjNhfOBu5Z1HmkLxNO2oB0RZbZL2kFk",
+//				"type": "Ed25519",
+//				"alg": "EdDSA",
+//				"createdAt": "2026-01-02T15:04:05Z",
+//				"active": true,
+//				"file": "ZhD6rKVgQ3ZoHjNhfOBu5Z1HmkLxNO2oB0RZbZL2kFk.pem"
+//			}
+//		]
+//	}
+//
+// It is safe for concurrent use in a process, a single process should write the directory.
+type DirKeystore struct {
+	dir        string
+	passphrase []byte
+
+	mu sync.Mutex
+}
+
+var _ Keystore = (*DirKeystore)(nil)
+
+// NewDirKeystore opens the keystore of the directory, the directory is created if it does not exist.
+func NewDirKeystore(dir string, passphrase []byte) (*DirKeystore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+	ks := &DirKeystore{dir: dir, passphrase: passphrase}
+	if _, err := ks.readManifest(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (ks *DirKeystore) List(ctx context.Context) ([]KeyInfo, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	m, err := ks.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]KeyInfo, 0, len(m.Keys))
+	for _, key := range m.Keys {
+		keys = append(keys, key.KeyInfo)
+	}
+	return keys, nil
+}
+
+func (ks *DirKeystore) Create(ctx context.Context, keyType string) (KeyInfo, error) {
+	return ks.create(keyType, false)
+}
+
+func (ks *DirKeystore) Rotate(ctx context.Context, keyType string) (KeyInfo, error) {
+	return ks.create(keyType, true)
+}
+
+func (ks *DirKeystore) Load(ctx context.Context, id string) (Signer, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	m, err := ks.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(m.Keys, func(key manifestKey) bool { return key.ID == id })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	return ks.load(m.Keys[i])
+}
+
+func (ks *DirKeystore) Active(ctx context.Context) (Signer, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	m, err := ks.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(m.Keys, func(key manifestKey) bool { return key.Active })
+	if i < 0 {
+		return nil, ErrNoActiveKey
+	}
+	return ks.load(m.Keys[i])
+}
+
+func (ks *DirKeystore) create(keyType string, activate bool) (KeyInfo, error) {
+	signer, err := generate(keyType)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	m, err := ks.readManifest()
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	key := manifestKey{
+		KeyInfo: KeyInfo{
+			ID:        signer.KeyID(),
+			Type:      keyType,
+			Algorithm: signer.Algorithm(),
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+		},
+		File: signer.KeyID() + ".pem",
+	}
+	if err := ks.writeKey(key.File, signer); err != nil {
+		return KeyInfo{}, err
+	}
+
+	hasActive := slices.ContainsFunc(m.Keys, func(key manifestKey) bool { return key.Active })
+	if activate || !hasActive {
+		for i := range m.Keys {
+			if m.Keys[i].Active {
+				m.Keys[i].Active = false
+				m.Keys[i].RetiredAt = &key.CreatedAt
+			}
+		}
+		key.Active = true
+	}
+	m.Keys = append(m.Keys, key)
+	if err := ks.writeManifest(m); err != nil {
+		return KeyInfo{}, err
+	}
+	return key.KeyInfo, nil
+}
+
+// generate creates a key of the type.
+func generate(keyType string) (Signer, error) {
+	switch keyType {
+	case KeyTypeEd25519:
+		return edKeys.GenerateKeyPair()
+	case KeyTypeP256:
+		return ecKeys.GenerateKeyPair(elliptic.P256())
+	case KeyTypeP384:
+		return ecKeys.GenerateKeyPair(elliptic.P384())
+	case KeyTypeP521:
+		return ecKeys.GenerateKeyPair(elliptic.P521())
+	case KeyTypeRSA:
+		return rsaKeys.GenerateKeyPair()
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+// privateKeyPEM is implemented by the key pairs.
+type privateKeyPEM interface {
+	PEM() (public []byte, private []byte, err error)
+	EncryptedPEM(passphrase []byte) (public []byte, private []byte, err error)
+}
+
+func (ks *DirKeystore) writeKey(file string, signer Signer) error {
+	keyPair, ok := signer.(privateKeyPEM)
+	if !ok {
+		return fmt.Errorf("unsupported key: %T", signer)
+	}
+	var (
+		private []byte
+		err     error
+	)
+	if len(ks.passphrase) > 0 {
+		_, private, err = keyPair.EncryptedPEM(ks.passphrase)
+	} else {
+		_, private, err = keyPair.PEM()
+	}
+	if err != nil {
+		return err
+	}
+	// O_EXCL: a key file is never overwritten
+	f, err := os.OpenFile(filepath.Join(ks.dir, file), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	if _, err := f.Write(private); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	return f.Close()
+}
+
+func (ks *DirKeystore) load(key manifestKey) (Signer, error) {
+	data, err := os.ReadFile(filepath.Join(ks.dir, filepath.Base(key.File)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", key.ID, err)
+	}
+
+	var signer Signer
+	switch key.Type {
+	case KeyTypeEd25519:
+		signer, err = loadPEM(data, ks.passphrase, edKeys.KeyPairFromPEM, edKeys.KeyPairFromEncryptedPEM)
+	case KeyTypeP256, KeyTypeP384, KeyTypeP521:
+		signer, err = loadPEM(data, ks.passphrase, ecKeys.KeyPairFromPEM, ecKeys.KeyPairFromEncryptedPEM)
+	case KeyTypeRSA:
+		signer, err = loadPEM(data, ks.passphrase, rsaKeys.KeyPairFromPEM, rsaKeys.KeyPairFromEncryptedPEM)
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", key.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key %s: %w", key.ID, err)
+	}
+	if signer.KeyID() != key.ID {
+		return nil, fmt.Errorf("key file of %s does not match its key ID", key.ID)
+	}
+	return signer, nil
+}
+
+func loadPEM[K Signer](data, passphrase []byte, fromPEM func([]byte) (K, error), fromEncryptedPEM func([]byte, []byte) (K, error)) (Signer, error) {
+	if len(passphrase) > 0 {
+		return fromEncryptedPEM(data, passphrase)
+	}
+	return fromPEM(data)
+}
+
+func (ks *DirKeystore) readManifest() (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(ks.dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// writeManifest replaces the manifest atomically, through a temporary file.
+func (ks *DirKeystore) writeManifest(m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(ks.dir, ManifestFile+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(ks.dir, ManifestFile)); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package keystore manages the signing keys by key ID.
+//
+// Signer is the common interface of the keys: the KeyPair types of the rsa, ed25519 and ecdsa packages
+// implement it, and so can a key held by a cloud KMS. The signers are accepted as is by the jwt and x509 packages.
+//
+// Keystore lists, creates, rotates and loads the keys. DirKeystore is the local implementation,
+// the keys are PEM files in a directory with a manifest:
+//
+//	store, err := keystore.NewDirKeystore("/etc/app/keys", passphrase)
+//	// ...
+//	if _, err := store.Rotate(ctx, keystore.KeyTypeEd25519); err != nil {
+//		// ...
+//	}
+//	signer, err := store.Active(ctx)
+//	str, err := jwt.SignWithClaims(signer, payload)
+package keystore
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"time"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+// Signer is a signing key identified by its key ID.
+type Signer interface {
+	crypto.Signer
+	// KeyID returns the ID of the key, e.g. the "kid" header of the tokens.
+	KeyID() string
+	// Algorithm returns the JWA name (RFC 7518) of the signatures, e.g. EdDSA, ES256 or RS256.
+	Algorithm() string
+}
+
+var (
+	_ Signer = (*rsaKeys.KeyPair)(nil)
+	_ Signer = (*edKeys.KeyPair)(nil)
+	_ Signer = (*ecKeys.KeyPair)(nil)
+)
+
+// The key types of Keystore.Create
+const (
+	KeyTypeEd25519 = "Ed25519"
+	KeyTypeP256    = "P-256"
+	KeyTypeP384    = "P-384"
+	KeyTypeP521    = "P-521"
+	KeyTypeRSA     = "RSA"
+)
+
+var (
+	// ErrKeyNotFound is returned when no key has the key ID.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrNoActiveKey is returned by Keystore.Active when no key has been created yet.
+	ErrNoActiveKey = errors.New("no active key")
+)
+
+// KeyInfo describes a key of the keystore.
+type KeyInfo struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`
+	Algorithm string     `json:"alg"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+	Active    bool       `json:"active,omitempty"`
+}
+
+// Keystore manages the signing keys.
+// The retired keys stay loadable, to verify the signatures made before a rotation.
+type Keystore interface {
+	// List returns the keys, the oldest first.
+	List(ctx context.Context) ([]KeyInfo, error)
+	// Create creates a key of the type, it becomes the active key if there is none.
+	Create(ctx context.Context, keyType string) (KeyInfo, error)
+	// Rotate creates a key of the type, makes it the active key and retires the previous one.
+	Rotate(ctx context.Context, keyType string) (KeyInfo, error)
+	// Load returns the key with the key ID.
+	Load(ctx context.Context, id string) (Signer, error)
+	// Active returns the active key.
+	Active(ctx context.Context) (Signer, error)
+}
@@ -0,0 +1,81 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+func TestDirKeystoreRotation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewDirKeystore(dir, []byte("correct-horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Active(ctx); !errors.Is(err, ErrNoActiveKey) {
+		t.Fatalf("expected no active key, got %v", err)
+	}
+
+	first, err := store.Create(ctx, KeyTypeEd25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Active || first.Algorithm != "EdDSA" {
+		t.Fatalf("unexpected key: %+v", first)
+	}
+	second, err := store.Rotate(ctx, KeyTypeP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The keystore is reopened from the directory
+	if store, err = NewDirKeystore(dir, []byte("correct-horse")); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Active || keys[0].RetiredAt == nil || !keys[1].Active || keys[1].ID != second.ID {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	signer, err := store.Active(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.KeyID() != second.ID || signer.Algorithm() != "ES256" {
+		t.Fatalf("unexpected active key: %s %s", signer.KeyID(), signer.Algorithm())
+	}
+	str, err := jwt.SignWithClaims(signer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jwt.ParseClaims(signer.Public(), str); err != nil {
+		t.Fatal(err)
+	}
+	// The key ID of the keystore is the thumbprint used by the jwt package
+	if kid, err := jwt.KeyId(signer); err != nil || kid != second.ID {
+		t.Fatalf("unexpected thumbprint: %s, %v", kid, err)
+	}
+
+	// The retired key is still loadable to verify the old signatures
+	if _, err := store.Load(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx, "unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected key not found, got %v", err)
+	}
+	wrong, err := NewDirKeystore(dir, []byte("battery-staple"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wrong.Load(ctx, first.ID); err == nil {
+		t.Fatal("expected an error for a wrong passphrase")
+	}
+}
@@ -0,0 +1,76 @@
+package otp
+
+import "time"
+
+// NewOption returns the default option, the one of the common authenticator apps:
+// 6 digits, a period of 30 seconds and SHA1, with a skew of one step.
+func NewOption() *Option {
+	return &Option{
+		digits:    6,
+		period:    30 * time.Second,
+		algorithm: AlgorithmSHA1,
+		skew:      1,
+	}
+}
+
+type Option struct {
+	digits    int
+	period    time.Duration
+	algorithm Algorithm
+
+	// Number of steps (TOTP) or counters (HOTP) accepted around the expected one
+	skew uint
+}
+
+// SetDigits sets the number of digits of the codes, from 6 to 8.
+func (src *Option) SetDigits(digits int) *Option {
+	dst := *src
+	dst.digits = digits
+	return &dst
+}
+
+func (src *Option) Digits() int {
+	return src.digits
+}
+
+// SetPeriod sets the time step of TOTP, in whole seconds.
+func (src *Option) SetPeriod(d time.Duration) *Option {
+	dst := *src
+	dst.period = d
+	return &dst
+}
+
+func (src *Option) Period() time.Duration {
+	return src.period
+}
+
+func (src *Option) SetAlgorithm(alg Algorithm) *Option {
+	dst := *src
+	dst.algorithm = alg
+	return &dst
+}
+
+func (src *Option) Algorithm() Algorithm {
+	return src.algorithm
+}
+
+// SetSkew sets the number of steps accepted before and after the current one (TOTP),
+// or the number of counters accepted after the expected one (HOTP look-ahead).
+func (src *Option) SetSkew(skew uint) *Option {
+	dst := *src
+	dst.skew = skew
+	return &dst
+}
+
+func (src *Option) Skew() uint {
+	return src.skew
+}
+
+func mergeOption(opts []*Option) *Option {
+	for _, opt := range opts {
+		if opt != nil {
+			return opt
+		}
+	}
+	return NewOption()
+}
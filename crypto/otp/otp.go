@@ -0,0 +1,158 @@
+// Package otp implements the one-time passwords of the second factor:
+// HOTP (RFC 4226) and TOTP (RFC 6238), compatible with the authenticator apps.
+//
+// Enrollment: generate a secret, show its provisioning URI as a QR code, store it encrypted (EncryptSecret)
+// with the hashes of the recovery codes. Login: verify the code with a Verifier, which rejects the codes already used,
+// then sign the token.
+//
+//	secret, err := otp.GenerateSecret()
+//	uri := otp.ProvisioningURI("Bankaool", user.Email, secret)
+//	// ...
+//	verifier := otp.NewVerifier()
+//	if err := verifier.VerifyTOTP(ctx, user.Id, secret, code); err != nil {
+//		// ErrInvalidCode or ErrCodeReplayed
+//	}
+//	str, err := jwt.SignWithClaims(keyPair, payload, jwt.NewOption().SetUserId(user.Id))
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"time"
+)
+
+// Algorithm is the HMAC hash function of the codes, named as in the provisioning URIs.
+type Algorithm string
+
+const (
+	AlgorithmSHA1   Algorithm = "SHA1"
+	AlgorithmSHA256 Algorithm = "SHA256"
+	AlgorithmSHA512 Algorithm = "SHA512"
+)
+
+var (
+	// ErrInvalidCode is returned when the code does not match.
+	ErrInvalidCode = errors.New("invalid one-time password")
+)
+
+// hash returns the hash function of the algorithm.
+func (alg Algorithm) hash() (func() hash.Hash, error) {
+	switch alg {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+}
+
+// GenerateSecret generates a random secret of the size of the HMAC output of the algorithm,
+// 20 bytes for SHA1 (RFC 4226, section 4).
+func GenerateSecret(opts ...*Option) ([]byte, error) {
+	h, err := mergeOption(opts).Algorithm().hash()
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, h().Size())
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// HOTP returns the code of the counter (RFC 4226, section 5.3).
+func HOTP(secret []byte, counter uint64, opts ...*Option) (string, error) {
+	opt := mergeOption(opts)
+	if err := checkOption(opt); err != nil {
+		return "", err
+	}
+	return hotp(secret, counter, opt), nil
+}
+
+// TOTP returns the code of the time step of t (RFC 6238, section 4).
+func TOTP(secret []byte, t time.Time, opts ...*Option) (string, error) {
+	opt := mergeOption(opts)
+	if err := checkOption(opt); err != nil {
+		return "", err
+	}
+	return hotp(secret, timeStep(t, opt.Period()), opt), nil
+}
+
+// VerifyHOTP verifies the code against the expected counter and the next ones within the skew (look-ahead window).
+// It returns the counter to expect next, to store for the following verification.
+func VerifyHOTP(secret []byte, code string, counter uint64, opts ...*Option) (uint64, error) {
+	opt := mergeOption(opts)
+	if err := checkOption(opt); err != nil {
+		return 0, err
+	}
+	for c := counter; c <= counter+uint64(opt.Skew()); c++ {
+		if equal(hotp(secret, c, opt), code) {
+			return c + 1, nil
+		}
+	}
+	return 0, ErrInvalidCode
+}
+
+// VerifyTOTP verifies the code against the time step of t and the steps within the skew before and after it.
+// It returns the matching time step. It does not reject a code already used, see Verifier.
+func VerifyTOTP(secret []byte, code string, t time.Time, opts ...*Option) (uint64, error) {
+	opt := mergeOption(opts)
+	if err := checkOption(opt); err != nil {
+		return 0, err
+	}
+	step := timeStep(t, opt.Period())
+	skew := uint64(opt.Skew())
+	for s := step - min(step, skew); s <= step+skew; s++ {
+		if equal(hotp(secret, s, opt), code) {
+			return s, nil
+		}
+	}
+	return 0, ErrInvalidCode
+}
+
+// hotp computes the HMAC of the counter and truncates it dynamically to the digits of the option.
+func hotp(secret []byte, counter uint64, opt *Option) string {
+	h, _ := opt.Algorithm().hash()
+	mac := hmac.New(h, secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	mod := uint32(1)
+	for range opt.Digits() {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", opt.Digits(), value%mod)
+}
+
+func timeStep(t time.Time, period time.Duration) uint64 {
+	return uint64(t.Unix()) / uint64(period/time.Second)
+}
+
+func checkOption(opt *Option) error {
+	if opt.Digits() < 6 || opt.Digits() > 8 {
+		return fmt.Errorf("unsupported number of digits: %d", opt.Digits())
+	}
+	if opt.Period() < time.Second || opt.Period()%time.Second != 0 {
+		return fmt.Errorf("invalid period: %s", opt.Period())
+	}
+	_, err := opt.Algorithm().hash()
+	return err
+}
+
+// equal compares the codes in constant time.
+func equal(expected, code string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1
+}
@@ -0,0 +1,240 @@
+package otp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-devkit/pkg/crypto/envelope"
+)
+
+func TestHOTP(t *testing.T) {
+	t.Parallel()
+
+	// RFC 4226, appendix D
+	secret := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, want := range expected {
+		got, err := HOTP(secret, uint64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("counter %d: expected %s, got %s", counter, want, got)
+		}
+	}
+
+	// Look-ahead window of one counter
+	next, err := VerifyHOTP(secret, "359152", 1)
+	if err != nil || next != 3 {
+		t.Fatalf("expected next counter 3, got %d %v", next, err)
+	}
+	if _, err := VerifyHOTP(secret, "969429", 1); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected ErrInvalidCode, got %v", err)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	t.Parallel()
+
+	// RFC 6238, appendix B
+	secrets := map[Algorithm][]byte{
+		AlgorithmSHA1:   []byte("12345678901234567890"),
+		AlgorithmSHA256: []byte("12345678901234567890123456789012"),
+		AlgorithmSHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	vectors := []struct {
+		unix int64
+		alg  Algorithm
+		code string
+	}{
+		{59, AlgorithmSHA1, "94287082"},
+		{59, AlgorithmSHA256, "46119246"},
+		{59, AlgorithmSHA512, "90693936"},
+		{1111111109, AlgorithmSHA1, "07081804"},
+		{1111111109, AlgorithmSHA256, "68084774"},
+		{1111111109, AlgorithmSHA512, "25091201"},
+		{1234567890, AlgorithmSHA1, "89005924"},
+		{1234567890, AlgorithmSHA256, "91819424"},
+		{1234567890, AlgorithmSHA512, "93441116"},
+		{2000000000, AlgorithmSHA1, "69279037"},
+		{2000000000, AlgorithmSHA256, "90698825"},
+		{2000000000, AlgorithmSHA512, "38618901"},
+	}
+	for _, v := range vectors {
+		opt := NewOption().SetDigits(8).SetAlgorithm(v.alg)
+		got, err := TOTP(secrets[v.alg], time.Unix(v.unix, 0), opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v.code {
+			t.Errorf("%d %s: expected %s, got %s", v.unix, v.alg, v.code, got)
+		}
+	}
+
+	// Skew of one step
+	secret := secrets[AlgorithmSHA1]
+	now := time.Unix(1234567890, 0)
+	previous, _ := TOTP(secret, now.Add(-30*time.Second))
+	if _, err := VerifyTOTP(secret, previous, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyTOTP(secret, previous, now, NewOption().SetSkew(0)); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected ErrInvalidCode, got %v", err)
+	}
+	if _, err := TOTP(secret, now, NewOption().SetDigits(4)); err == nil {
+		t.Fatal("expected an error for 4 digits")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	t.Parallel()
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier()
+	code, err := TOTP(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := verifier.VerifyTOTP(ctx, "alice", secret, code); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifyTOTP(ctx, "alice", secret, code); !errors.Is(err, ErrCodeReplayed) {
+		t.Fatalf("expected ErrCodeReplayed, got %v", err)
+	}
+
+	// A code of an earlier step is rejected once a later step has been accepted
+	now := time.Now()
+	next, err := TOTP(secret, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := TOTP(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifyTOTP(ctx, "bob", secret, next); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.VerifyTOTP(ctx, "bob", secret, previous); !errors.Is(err, ErrCodeReplayed) {
+		t.Fatalf("expected ErrCodeReplayed for an earlier step, got %v", err)
+	}
+}
+
+func TestVerifierHOTP(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	secret := []byte("12345678901234567890")
+	counters := NewMemoryCounterStore()
+	verifier := NewVerifier(NewOption().SetSkew(2)).SetCounterStore(counters)
+
+	// The code of counter 1 is within the look-ahead window of counter 0
+	if err := verifier.VerifyHOTP(ctx, "alice", secret, "287082"); err != nil {
+		t.Fatal(err)
+	}
+	if counter, _ := counters.Counter(ctx, "alice"); counter != 2 {
+		t.Fatalf("expected counter 2, got %d", counter)
+	}
+	// The code, and the code of an earlier counter, are no longer accepted
+	for _, code := range []string{"287082", "755224"} {
+		if err := verifier.VerifyHOTP(ctx, "alice", secret, code); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("expected ErrInvalidCode for %s, got %v", code, err)
+		}
+	}
+
+	// A verification racing with another one loses the compare-and-set
+	race := &racingCounterStore{MemoryCounterStore: counters}
+	if err := verifier.SetCounterStore(race).VerifyHOTP(ctx, "alice", secret, "359152"); !errors.Is(err, ErrCodeReplayed) {
+		t.Fatalf("expected ErrCodeReplayed, got %v", err)
+	}
+}
+
+// racingCounterStore advances the counter between Counter and CompareAndSwap, like a concurrent verification.
+type racingCounterStore struct {
+	*MemoryCounterStore
+}
+
+func (s *racingCounterStore) Counter(ctx context.Context, account string) (uint64, error) {
+	counter, err := s.MemoryCounterStore.Counter(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.MemoryCounterStore.CompareAndSwap(ctx, account, counter, counter+1)
+	return counter, err
+}
+
+func TestProvisioningURI(t *testing.T) {
+	t.Parallel()
+
+	secret, err := DecodeSecret("JBSW Y3DP EHPK 3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ProvisioningURI("Example Bank", "alice@example.com", secret)
+	want := "otpauth://totp/Example%20Bank:alice@example.com?algorithm=SHA1&digits=6&issuer=Example%20Bank&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	t.Parallel()
+
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		if len(code) != recoveryLength+1 {
+			t.Fatalf("unexpected recovery code %q", code)
+		}
+		hashes[i] = HashRecoveryCode(code)
+	}
+	// Case and dashes are ignored
+	index, err := VerifyRecoveryCode(" "+codes[3][:5]+codes[3][6:], hashes)
+	if err != nil || index != 3 {
+		t.Fatalf("expected index 3, got %d %v", index, err)
+	}
+	if _, err := VerifyRecoveryCode("aaaaa-aaaaa", hashes); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected ErrInvalidCode, got %v", err)
+	}
+}
+
+func TestEncryptSecret(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kms, err := envelope.OpenKeyfile(filepath.Join(t.TempDir(), "master-keys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kms.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	keyring := envelope.NewKeyring(kms)
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := EncryptSecret(ctx, keyring, "alice", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecryptSecret(ctx, keyring, "alice", ciphertext)
+	if err != nil || !bytes.Equal(got, secret) {
+		t.Fatalf("failed to decrypt secret: %v", err)
+	}
+	if _, err := DecryptSecret(ctx, keyring, "bob", ciphertext); !errors.Is(err, envelope.ErrDecrypt) {
+		t.Fatalf("expected envelope.ErrDecrypt, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Alphabet of the recovery codes, without the characters easily confused (0/o, 1/l/i)
+	recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+	recoveryLength   = 10
+)
+
+// GenerateRecoveryCodes generates n recovery codes of 10 characters (about 50 bits each), e.g. "7kq4m-x2vna".
+// They are shown once to the user, only their hashes are stored (see HashRecoveryCode).
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	buf := make([]byte, recoveryLength)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == recoveryLength/2 {
+				sb.WriteByte('-')
+			}
+			// 256 is not a multiple of the alphabet size, the bias is negligible for the entropy of a code
+			sb.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = sb.String()
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the SHA-256 of the normalized code in hex, the form to store.
+// The codes are random, a slow password hash is not needed.
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyRecoveryCode returns the index of the hash of the code, the entry to remove once the code is used.
+// The case, spaces and dashes of the code are ignored.
+func VerifyRecoveryCode(code string, hashes []string) (int, error) {
+	hash := []byte(HashRecoveryCode(code))
+	index := -1
+	// Compare every hash, in constant time
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare(hash, []byte(h)) == 1 {
+			index = i
+		}
+	}
+	if index < 0 {
+		return -1, fmt.Errorf("%w: unknown recovery code", ErrInvalidCode)
+	}
+	return index, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
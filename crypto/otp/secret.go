@@ -0,0 +1,22 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/golang-devkit/pkg/crypto/envelope"
+)
+
+// EncryptSecret encrypts the secret of the account with the keyring, the form to store.
+// The ciphertext is bound to the account: it cannot be decrypted for another account.
+func EncryptSecret(ctx context.Context, keyring *envelope.Keyring, account string, secret []byte) ([]byte, error) {
+	return keyring.Encrypt(ctx, secret, secretAAD(account))
+}
+
+// DecryptSecret decrypts the secret of the account encrypted by EncryptSecret.
+func DecryptSecret(ctx context.Context, keyring *envelope.Keyring, account string, ciphertext []byte) ([]byte, error) {
+	return keyring.Decrypt(ctx, ciphertext, secretAAD(account))
+}
+
+func secretAAD(account string) []byte {
+	return []byte("otp.secret:" + account)
+}
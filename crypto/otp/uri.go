@@ -0,0 +1,67 @@
+package otp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// encoding is the base32 encoding of the secrets in the provisioning URIs, without padding
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ProvisioningURI returns the otpauth:// URI of a TOTP secret, shown as a QR code to the authenticator app.
+//
+// Example:
+//
+//	otpauth://totp/Bankaool:alice@example.com?algorithm=SHA1&digits=6&issuer=Bankaool&period=30&secret=JBSWY3DPEHPK3PXP
+func ProvisioningURI(issuer, account string, secret []byte, opts ...*Option) string {
+	opt := mergeOption(opts)
+	query := uriQuery(issuer, secret, opt)
+	query.Set("period", strconv.Itoa(int(opt.Period().Seconds())))
+	return uri("totp", issuer, account, query)
+}
+
+// HOTPProvisioningURI returns the otpauth:// URI of a HOTP secret, with the initial counter.
+func HOTPProvisioningURI(issuer, account string, secret []byte, counter uint64, opts ...*Option) string {
+	query := uriQuery(issuer, secret, mergeOption(opts))
+	query.Set("counter", strconv.FormatUint(counter, 10))
+	return uri("hotp", issuer, account, query)
+}
+
+// EncodeSecret returns the secret in base32, to be typed in the authenticator app instead of scanning the URI.
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// DecodeSecret decodes a base32 secret, with or without padding and spaces.
+func DecodeSecret(str string) ([]byte, error) {
+	str = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(str, " ", ""), "="))
+	return encoding.DecodeString(str)
+}
+
+func uriQuery(issuer string, secret []byte, opt *Option) url.Values {
+	query := url.Values{}
+	query.Set("secret", EncodeSecret(secret))
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", string(opt.Algorithm()))
+	query.Set("digits", strconv.Itoa(opt.Digits()))
+	return query
+}
+
+func uri(kind, issuer, account string, query url.Values) string {
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+	u := url.URL{
+		Scheme: "otpauth",
+		Host:   kind,
+		Path:   "/" + label,
+		// Spaces as %20 rather than "+", which some apps display as is
+		RawQuery: strings.ReplaceAll(query.Encode(), "+", "%20"),
+	}
+	return u.String()
+}
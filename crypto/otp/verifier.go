@@ -0,0 +1,154 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCodeReplayed is returned when the code, or a code of a later time step, has already been used.
+	ErrCodeReplayed = errors.New("one-time password has already been used")
+)
+
+// StepStore remembers the last time step accepted for each account (RFC 6238, section 5.2).
+type StepStore interface {
+	// Accept records the step for the account if it is greater than the last recorded step,
+	// and reports whether it was recorded. It must be atomic.
+	Accept(ctx context.Context, account string, step uint64) (bool, error)
+}
+
+// MemoryStepStore is an in-memory StepStore, for a single instance.
+// It keeps the last step of every account verified since the start of the process and never evicts them:
+// its size grows with the number of accounts. Use a shared store with an expiry of a few periods
+// (e.g. a Redis key per account) for a large number of accounts.
+type MemoryStepStore struct {
+	mu    sync.Mutex
+	steps map[string]uint64
+}
+
+func NewMemoryStepStore() *MemoryStepStore {
+	return &MemoryStepStore{steps: make(map[string]uint64)}
+}
+
+func (s *MemoryStepStore) Accept(ctx context.Context, account string, step uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.steps[account]; ok && step <= last {
+		return false, nil
+	}
+	s.steps[account] = step
+	return true, nil
+}
+
+// CounterStore keeps the HOTP counter of each account, the counter to expect next (RFC 4226, section 7.2).
+type CounterStore interface {
+	// Counter returns the counter of the account, 0 for an unknown account.
+	Counter(ctx context.Context, account string) (uint64, error)
+	// CompareAndSwap sets the counter of the account to next if it is still old, and reports whether it was set.
+	// It must be atomic: of two verifications of the same code, only one succeeds.
+	CompareAndSwap(ctx context.Context, account string, old, next uint64) (bool, error)
+}
+
+// MemoryCounterStore is an in-memory CounterStore, for a single instance or for tests.
+// The counters are lost when the process stops, a persistent store is required in production.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+func NewMemoryCounterStore() *MemoryCounterStore {
+	return &MemoryCounterStore{counters: make(map[string]uint64)}
+}
+
+func (s *MemoryCounterStore) Counter(ctx context.Context, account string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counters[account], nil
+}
+
+func (s *MemoryCounterStore) CompareAndSwap(ctx context.Context, account string, old, next uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counters[account] != old {
+		return false, nil
+	}
+	s.counters[account] = next
+	return true, nil
+}
+
+// Verifier verifies the TOTP codes and accepts each time step of an account once,
+// and verifies the HOTP codes and advances the counter of the account.
+type Verifier struct {
+	opt      *Option
+	steps    StepStore
+	counters CounterStore
+}
+
+// NewVerifier creates a Verifier with the option, with in-memory step and counter stores.
+func NewVerifier(opts ...*Option) *Verifier {
+	return &Verifier{
+		opt:      mergeOption(opts),
+		steps:    NewMemoryStepStore(),
+		counters: NewMemoryCounterStore(),
+	}
+}
+
+// SetStepStore replaces the in-memory step store, e.g. by a shared store for several instances.
+func (src *Verifier) SetStepStore(store StepStore) *Verifier {
+	dst := *src
+	dst.steps = store
+	return &dst
+}
+
+// SetCounterStore replaces the in-memory counter store, e.g. by the collection of the enrolled HOTP tokens.
+func (src *Verifier) SetCounterStore(store CounterStore) *Verifier {
+	dst := *src
+	dst.counters = store
+	return &dst
+}
+
+func (src *Verifier) Option() *Option {
+	return src.opt
+}
+
+// VerifyTOTP verifies the TOTP code of the account (see VerifyTOTP), then records its time step
+// as the last accepted step of the account: the code, and any code of the same or an earlier step,
+// is rejected with ErrCodeReplayed afterwards.
+func (v *Verifier) VerifyTOTP(ctx context.Context, account string, secret []byte, code string) error {
+	step, err := VerifyTOTP(secret, code, time.Now(), v.opt)
+	if err != nil {
+		return err
+	}
+	accepted, err := v.steps.Accept(ctx, account, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrCodeReplayed
+	}
+	return nil
+}
+
+// VerifyHOTP verifies the HOTP code of the account against its counter (see VerifyHOTP),
+// then advances the counter past the matching one with a compare-and-set:
+// the code is rejected with ErrCodeReplayed when the counter was advanced concurrently by the same or another code.
+func (v *Verifier) VerifyHOTP(ctx context.Context, account string, secret []byte, code string) error {
+	counter, err := v.counters.Counter(ctx, account)
+	if err != nil {
+		return err
+	}
+	next, err := VerifyHOTP(secret, code, counter, v.opt)
+	if err != nil {
+		return err
+	}
+	swapped, err := v.counters.CompareAndSwap(ctx, account, counter, next)
+	if err != nil {
+		return err
+	}
+	if !swapped {
+		return ErrCodeReplayed
+	}
+	return nil
+}
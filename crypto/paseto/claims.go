@@ -0,0 +1,123 @@
+package paseto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+// Claims defines the PASETO claims, the same shape as jwt.MapClaims.
+// The registered claims follow the PASETO specification: the times are RFC 3339 strings.
+//
+// Example:
+//
+//	{
+//		  "iss": "Issuer",
+//		  "sub": "Subject",
+//		  "aud": "LOGIN",
+//		  "jti": "5f8c0a0e-6b2f-4f0e-9a43-2a7c9d1b6f3e",
+//		  "nbf": "2026-01-02T15:04:05Z",
+//		  "iat": "2026-01-02T15:04:05Z",
+//		  "exp": "2026-01-02T15:05:35Z",
+//		  "userId": "6883664f484674420f55c16b",
+//		  "sessionId": "0cf835de-5c39-481d-a371-94884ba91fcd"
+//		}
+type Claims struct {
+	Issuer    string     `json:"iss,omitempty"`
+	Subject   string     `json:"sub,omitempty"`
+	Audience  Audience   `json:"aud,omitempty"`
+	ID        string     `json:"jti,omitempty"`
+	ExpiresAt *time.Time `json:"exp,omitempty"`
+	NotBefore *time.Time `json:"nbf,omitempty"`
+	IssuedAt  *time.Time `json:"iat,omitempty"`
+
+	SessionId        string            `json:"sessionId"`
+	UserId           string            `json:"userId"`
+	ProtobufDataHash string            `json:"protobufDataHash,omitempty"`
+	TenantId         string            `json:"tenantId,omitempty"`
+	Roles            []string          `json:"roles,omitempty"`
+	Scopes           []string          `json:"scopes,omitempty"`
+	Confirmation     *jwt.Confirmation `json:"cnf,omitempty"`
+	Payload          any               `json:"payload,omitempty"`
+}
+
+// Audience is the "aud" claim, encoded as a string when there is a single audience.
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	// null is no audience, not a single empty one
+	if string(bytes.TrimSpace(b)) == "null" {
+		*a = nil
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// MapClaims converts the claims to jwt.MapClaims, so the handlers do not depend on the token format.
+func (claims *Claims) MapClaims() *jwt.MapClaims {
+	numericDate := func(t *time.Time) *gojwt.NumericDate {
+		if t == nil {
+			return nil
+		}
+		return gojwt.NewNumericDate(*t)
+	}
+	return &jwt.MapClaims{
+		RegisteredClaims: gojwt.RegisteredClaims{
+			ID:        claims.ID,
+			Issuer:    claims.Issuer,
+			Subject:   claims.Subject,
+			Audience:  gojwt.ClaimStrings(claims.Audience),
+			ExpiresAt: numericDate(claims.ExpiresAt),
+			NotBefore: numericDate(claims.NotBefore),
+			IssuedAt:  numericDate(claims.IssuedAt),
+		},
+		SessionId:        claims.SessionId,
+		UserId:           claims.UserId,
+		ProtobufDataHash: claims.ProtobufDataHash,
+		TenantId:         claims.TenantId,
+		Roles:            claims.Roles,
+		Scopes:           claims.Scopes,
+		Confirmation:     claims.Confirmation,
+		Payload:          claims.Payload,
+	}
+}
+
+// ParsePayload parses the claims payload into the given struct.
+// If v is proto.Message, please use Claims.ParseMessage(v proto.Message) instead.
+func (claims *Claims) ParsePayload(v any) error {
+	return claims.MapClaims().ParsePayload(v)
+}
+
+// ParseMessage parses the claims payload into the given proto.Message.
+func (claims *Claims) ParseMessage(v proto.Message) error {
+	return claims.MapClaims().ParseMessage(v)
+}
+
+// ApplyContext applies the claims to the given context, like jwt.MapClaims.ApplyContext:
+// the values are read with jwt.ClaimsFromContext, jwt.SessionIdFromContext, jwt.UserIdFromContext, etc.
+func (claims *Claims) ApplyContext(ctx context.Context) context.Context {
+	return claims.MapClaims().ApplyContext(ctx)
+}
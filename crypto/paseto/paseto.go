@@ -0,0 +1,240 @@
+// Package paseto issues and verifies PASETO v4.public tokens (https://github.com/paseto-standard/paseto-spec).
+//
+// Unlike JWT, the version and purpose of the token fix the algorithm (Ed25519),
+// so there is no "alg" header to confuse. The claims have the same shape as jwt.MapClaims
+// and are applied to the context the same way, the handlers do not depend on the token format.
+package paseto
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+const (
+	// headerV4Public is the header of the v4.public tokens
+	headerV4Public = "v4.public."
+)
+
+var (
+	ErrInvalidToken          = errors.New("token is malformed")
+	ErrSignatureInvalid      = errors.New("token signature is invalid")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+	ErrTokenInvalidIssuer    = errors.New("token has invalid issuer")
+	ErrTokenInvalidAudience  = errors.New("token has invalid audience")
+)
+
+// footer is the JSON footer of the token, it is authenticated but not encrypted.
+type footer struct {
+	Kid string `json:"kid,omitempty"`
+}
+
+// Sign issues a v4.public token with the claims built from the options, as jwt.SignWithClaims does.
+// The footer contains the key ID: jwt.Option.KeyId, by default the RFC 7638 thumbprint of the public key.
+//
+// Example:
+//
+//	str, err := paseto.Sign(keyPair, payload, jwt.NewOption().SetUserId(userId))
+//	// ...
+//	claims, err := paseto.Parse(keyPair.PublicKey, str, jwt.NewParseOption().SetAudience("LOGIN"))
+func Sign(key *edKeys.KeyPair, payload any, opts ...*jwt.Option) (string, error) {
+	if key == nil || len(key.PrivateKey) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid key pair")
+	}
+	opt := jwt.MergeOption(opts...)
+
+	now := time.Now().Truncate(time.Second)
+	var (
+		notBefore = now.Add(opt.NotBeforeOffset())
+		expiresAt = now.Add(opt.LiveTime())
+	)
+	claims := Claims{
+		Issuer:           opt.Issuer(),
+		Subject:          opt.Subject(),
+		Audience:         opt.Audience(),
+		ID:               uuid.NewString(),
+		ExpiresAt:        &expiresAt,
+		NotBefore:        &notBefore,
+		IssuedAt:         &now,
+		SessionId:        opt.SessionId(),
+		UserId:           opt.UserId(),
+		ProtobufDataHash: opt.ProtoDataHash(),
+		TenantId:         opt.TenantId(),
+		Roles:            opt.Roles(),
+		Scopes:           opt.Scopes(),
+		Payload:          payload,
+	}
+	if jkt := opt.DPoPThumbprint(); jkt != "" {
+		claims.Confirmation = &jwt.Confirmation{JKT: jkt}
+	}
+	m, err := json.Marshal(&claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	kid := opt.KeyId()
+	if kid == "" {
+		if kid, err = jwt.KeyId(key.PublicKey); err != nil {
+			return "", err
+		}
+	}
+	f, err := json.Marshal(footer{Kid: kid})
+	if err != nil {
+		return "", err
+	}
+
+	sig := ed25519.Sign(key.PrivateKey, pae([]byte(headerV4Public), m, f, nil))
+
+	return headerV4Public +
+		base64.RawURLEncoding.EncodeToString(append(m, sig...)) + "." +
+		base64.RawURLEncoding.EncodeToString(f), nil
+}
+
+// Parse verifies a v4.public token and validates its claims with the options, as jwt.ParseClaims does:
+// expiration, not before and issued at (with the leeway), issuer, audience and revocation.
+func Parse(pub ed25519.PublicKey, str string, opts ...*jwt.ParseOption) (*Claims, error) {
+	return parse(context.Background(), pub, str, jwt.MergeParseOption(opts...))
+}
+
+// Footer returns the key ID of the token footer, without verifying the token.
+// It can be used to select the verification key.
+func Footer(str string) (kid string, err error) {
+	_, f, err := split(str)
+	if err != nil {
+		return "", err
+	}
+	if len(f) == 0 {
+		return "", nil
+	}
+	var v footer
+	if err := json.Unmarshal(f, &v); err != nil {
+		return "", fmt.Errorf("%w: invalid footer", ErrInvalidToken)
+	}
+	return v.Kid, nil
+}
+
+// NewVerifier returns a jwt.Verifier of the v4.public tokens,
+// e.g. to authenticate with PASETO in net.JWTAuthMiddleware without changing the handlers.
+func NewVerifier(pub ed25519.PublicKey) jwt.Verifier {
+	return jwt.VerifierFunc(func(ctx context.Context, str string, opts ...*jwt.ParseOption) (*jwt.MapClaims, error) {
+		claims, err := parse(ctx, pub, str, jwt.MergeParseOption(opts...))
+		if err != nil {
+			return nil, err
+		}
+		return claims.MapClaims(), nil
+	})
+}
+
+func parse(ctx context.Context, pub ed25519.PublicKey, str string, opt *jwt.ParseOption) (*Claims, error) {
+	entry := logger.NewEntry().With(zap.String(logger.KeyFunctionName, "paseto.Parse"))
+
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+	body, f, err := split(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+	m, sig := body[:len(body)-ed25519.SignatureSize], body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(pub, pae([]byte(headerV4Public), m, f, nil), sig) {
+		entry.Warn("Chữ ký không hợp lệ")
+		return nil, ErrSignatureInvalid
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(m, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if err := validate(&claims, opt, time.Now()); err != nil {
+		entry.Warn("Token không hợp lệ", zap.String(logger.KeyError, err.Error()))
+		return nil, err
+	}
+	if store := opt.RevocationStore(); store != nil {
+		revoked, err := store.IsRevoked(ctx, claims.MapClaims())
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			entry.Warn("Token đã bị thu hồi", zap.String("jti", claims.ID))
+			return nil, jwt.ErrTokenRevoked
+		}
+	}
+	return &claims, nil
+}
+
+// split returns the decoded body (message and signature) and footer of a v4.public token.
+func split(str string) (body, f []byte, err error) {
+	if !strings.HasPrefix(str, headerV4Public) {
+		return nil, nil, fmt.Errorf("%w: expected %q header", ErrInvalidToken, headerV4Public)
+	}
+	parts := strings.Split(str[len(headerV4Public):], ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+	if body, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if len(parts) == 2 {
+		if f, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+	}
+	return body, f, nil
+}
+
+func validate(claims *Claims, opt *jwt.ParseOption, now time.Time) error {
+	leeway := opt.Leeway()
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if !now.Before(claims.ExpiresAt.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(leeway).Before(*claims.NotBefore) {
+		return ErrTokenNotValidYet
+	}
+	if claims.IssuedAt != nil && now.Add(leeway).Before(*claims.IssuedAt) {
+		return ErrTokenUsedBeforeIssued
+	}
+	if issuer := opt.Issuer(); issuer != "" && claims.Issuer != issuer {
+		return ErrTokenInvalidIssuer
+	}
+	if audience := opt.Audience(); len(audience) > 0 && !slices.ContainsFunc(audience, func(aud string) bool {
+		return slices.Contains(claims.Audience, aud)
+	}) {
+		return ErrTokenInvalidAudience
+	}
+	return nil
+}
+
+// pae is the Pre-Authentication Encoding of the PASETO specification:
+// the number of pieces, then each piece prefixed by its length, as 64-bit little-endian integers.
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(len(pieces)))
+	for _, piece := range pieces {
+		_ = binary.Write(&buf, binary.LittleEndian, uint64(len(piece)))
+		buf.Write(piece)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,106 @@
+package paseto
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+// Test vector 4-S-1 of the PASETO specification
+func TestParseSpecVector(t *testing.T) {
+	t.Parallel()
+
+	pub, err := hex.DecodeString("1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	str := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9" +
+		"bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	// The signature is valid, the token has expired in 2022
+	if _, err := Parse(ed25519.PublicKey(pub), str); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected expired token, got %v", err)
+	}
+	if _, err := Parse(ed25519.PublicKey(pub), str[:len(str)-2]+"AA"); !errors.Is(err, ErrSignatureInvalid) {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+}
+
+func TestSignParse(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	str, err := Sign(keyPair, map[string]string{"account": "0001"},
+		jwt.NewOption().SetUserId("user-1").SetAudience("PAYMENT").SetKeyId("2026-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := Parse(keyPair.PublicKey, str, jwt.NewParseOption().SetAudience("PAYMENT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload map[string]string
+	if err := claims.ParsePayload(&payload); err != nil || payload["account"] != "0001" {
+		t.Fatalf("unexpected payload: %v, %v", payload, err)
+	}
+	if kid, err := Footer(str); err != nil || kid != "2026-01" {
+		t.Fatalf("unexpected key ID: %q, %v", kid, err)
+	}
+
+	// Same context values as a JWT
+	ctx := claims.ApplyContext(context.Background())
+	if jwt.UserIdFromContext(ctx) != "user-1" || jwt.ClaimsFromContext(ctx).ID != claims.ID {
+		t.Fatal("claims are not applied to the context")
+	}
+
+	if _, err := Parse(keyPair.PublicKey, str, jwt.NewParseOption().SetAudience("LOGIN")); !errors.Is(err, ErrTokenInvalidAudience) {
+		t.Fatalf("expected invalid audience, got %v", err)
+	}
+	if _, err := Parse(keyPair.PublicKey, strings.Replace(str, "v4.public.", "v3.public.", 1)); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected invalid token, got %v", err)
+	}
+
+	store := jwt.NewMemoryRevocationStore()
+	if err := jwt.RevokeClaims(context.Background(), store, claims.MapClaims()); err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier(keyPair.PublicKey)
+	if _, err := verifier.Verify(context.Background(), str, jwt.NewParseOption().SetRevocationStore(store)); !errors.Is(err, jwt.ErrTokenRevoked) {
+		t.Fatalf("expected revoked token, got %v", err)
+	}
+}
+
+func TestAudienceUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		json string
+		want Audience
+	}{
+		{json: `null`, want: nil},
+		{json: `"PAYMENT"`, want: Audience{"PAYMENT"}},
+		{json: `["PAYMENT","LOGIN"]`, want: Audience{"PAYMENT", "LOGIN"}},
+		{json: `[]`, want: Audience{}},
+	}
+	for _, tt := range tests {
+		var aud Audience
+		if err := json.Unmarshal([]byte(tt.json), &aud); err != nil {
+			t.Fatalf("%s: %v", tt.json, err)
+		}
+		if !slices.Equal(aud, tt.want) || (aud == nil) != (tt.want == nil) {
+			t.Fatalf("%s: expected %#v, got %#v", tt.json, tt.want, aud)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package password
+
+// NewOption returns the default argon2id parameters, the second recommended option of RFC 9106:
+// 64 MiB of memory, 3 iterations and 4 lanes, with a 16-byte salt and a 32-byte key.
+//
+// The parameters are tuned per environment, e.g. less memory for the tests or a small container:
+//
+//	opt := password.NewOption().SetMemory(19 * 1024).SetIterations(2).SetParallelism(1)
+//	hash, err := password.Hash(pwd, opt)
+func NewOption() *Option {
+	return &Option{
+		memory:      64 * 1024,
+		iterations:  3,
+		parallelism: 4,
+		saltLength:  16,
+		keyLength:   32,
+	}
+}
+
+type Option struct {
+	// Memory in KiB
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+
+	// Length in bytes
+	saltLength, keyLength uint32
+}
+
+// SetMemory sets the memory of argon2id in KiB, at least 8 KiB per lane and at most 1 GiB.
+func (src *Option) SetMemory(kib uint32) *Option {
+	dst := *src
+	dst.memory = kib
+	return &dst
+}
+
+func (src *Option) Memory() uint32 {
+	return src.memory
+}
+
+// SetIterations sets the number of passes over the memory (argon2 time parameter), from 1 to 64.
+func (src *Option) SetIterations(iterations uint32) *Option {
+	dst := *src
+	dst.iterations = iterations
+	return &dst
+}
+
+func (src *Option) Iterations() uint32 {
+	return src.iterations
+}
+
+// SetParallelism sets the number of lanes (argon2 threads parameter), from 1 to 64.
+func (src *Option) SetParallelism(parallelism uint8) *Option {
+	dst := *src
+	dst.parallelism = parallelism
+	return &dst
+}
+
+func (src *Option) Parallelism() uint8 {
+	return src.parallelism
+}
+
+func (src *Option) SetSaltLength(length uint32) *Option {
+	dst := *src
+	dst.saltLength = length
+	return &dst
+}
+
+func (src *Option) SaltLength() uint32 {
+	return src.saltLength
+}
+
+func (src *Option) SetKeyLength(length uint32) *Option {
+	dst := *src
+	dst.keyLength = length
+	return &dst
+}
+
+func (src *Option) KeyLength() uint32 {
+	return src.keyLength
+}
+
+func mergeOption(opts []*Option) *Option {
+	for _, opt := range opts {
+		if opt != nil {
+			return opt
+		}
+	}
+	return NewOption()
+}
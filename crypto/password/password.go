@@ -0,0 +1,184 @@
+// Package password hashes and verifies the passwords of the users.
+//
+// The passwords are hashed with argon2id (RFC 9106), encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+//
+// The bcrypt hashes ($2a$, $2b$, $2y$) of older systems are verified too, and reported as outdated,
+// so they are migrated to argon2id on the next login:
+//
+//	newHash, err := password.VerifyAndRehash(pwd, user.PasswordHash)
+//	if err != nil {
+//		// wrong password (ErrMismatch) or invalid hash
+//	}
+//	if newHash != "" {
+//		// store newHash
+//	}
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	prefixArgon2id = "$argon2id$"
+
+	// Upper bounds of the argon2id parameters, a stored hash cannot make Verify allocate
+	// gigabytes of memory or run for minutes
+	maxMemory      = 1 << 20 // KiB, 1 GiB
+	maxIterations  = 64
+	maxParallelism = 64
+)
+
+var (
+	// ErrMismatch is returned when the password does not match the hash.
+	ErrMismatch = errors.New("password does not match")
+	// ErrInvalidHash is returned when the hash is neither an argon2id PHC string nor a bcrypt hash.
+	ErrInvalidHash = errors.New("invalid password hash")
+)
+
+// Hash hashes the password with argon2id and the parameters of the option (see NewOption).
+func Hash(password string, opts ...*Option) (string, error) {
+	opt := mergeOption(opts)
+	p := params{
+		memory:      opt.Memory(),
+		iterations:  opt.Iterations(),
+		parallelism: opt.Parallelism(),
+		salt:        make([]byte, opt.SaltLength()),
+	}
+	if !p.valid() || opt.KeyLength() < 16 || opt.SaltLength() < 8 {
+		return "", errors.New("invalid argon2id parameters")
+	}
+	if _, err := rand.Read(p.salt); err != nil {
+		return "", err
+	}
+	p.key = argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, opt.KeyLength())
+	return p.String(), nil
+}
+
+// Verify reports whether the password matches the argon2id or bcrypt hash, it returns ErrMismatch if not.
+// The keys are compared in constant time.
+func Verify(password, hash string) error {
+	if isBcrypt(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+		}
+		return nil
+	}
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	if subtle.ConstantTimeCompare(key, p.key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash reports whether the hash was not made with the parameters of the option:
+// a bcrypt hash, or an argon2id hash with other parameters.
+func NeedsRehash(hash string, opts ...*Option) bool {
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	opt := mergeOption(opts)
+	return p.memory != opt.Memory() ||
+		p.iterations != opt.Iterations() ||
+		p.parallelism != opt.Parallelism() ||
+		uint32(len(p.salt)) != opt.SaltLength() ||
+		uint32(len(p.key)) != opt.KeyLength()
+}
+
+// VerifyAndRehash verifies the password as Verify does, then rehashes it when the hash is outdated (see NeedsRehash).
+// It returns the new hash to store, or an empty string when the hash is up to date.
+func VerifyAndRehash(password, hash string, opts ...*Option) (string, error) {
+	if err := Verify(password, hash); err != nil {
+		return "", err
+	}
+	if !NeedsRehash(hash, opts...) {
+		return "", nil
+	}
+	return Hash(password, opts...)
+}
+
+func isBcrypt(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// params are the fields of an argon2id PHC string.
+type params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt, key   []byte
+}
+
+// valid reports whether the cost parameters are within the bounds,
+// argon2 requires at least 8 KiB of memory per lane.
+func (p *params) valid() bool {
+	return p.iterations >= 1 && p.iterations <= maxIterations &&
+		p.parallelism >= 1 && p.parallelism <= maxParallelism &&
+		p.memory >= 8*uint32(p.parallelism) && p.memory <= maxMemory
+}
+
+func (p *params) String() string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", prefixArgon2id, argon2.Version,
+		p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(p.salt),
+		base64.RawStdEncoding.EncodeToString(p.key))
+}
+
+// parseArgon2id parses an argon2id PHC string, the salt and key are in unpadded base64.
+func parseArgon2id(hash string) (*params, error) {
+	if !strings.HasPrefix(hash, prefixArgon2id) {
+		return nil, ErrInvalidHash
+	}
+	parts := strings.Split(hash[len(prefixArgon2id):], "$")
+	if len(parts) != 4 {
+		return nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrInvalidHash, version)
+	}
+	p := &params{}
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if !p.valid() {
+		return nil, fmt.Errorf("%w: argon2id parameters out of bounds", ErrInvalidHash)
+	}
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[3]); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if len(p.key) < 16 {
+		return nil, ErrInvalidHash
+	}
+	return p, nil
+}
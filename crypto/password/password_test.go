@@ -0,0 +1,99 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testOption keeps the tests fast
+var testOption = NewOption().SetMemory(1024).SetIterations(1).SetParallelism(1)
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Hash("correct horse", testOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+	if err := Verify("correct horse", hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("wrong horse", hash); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+	if err := Verify("correct horse", "$argon2id$v=19$m=1024"); !errors.Is(err, ErrInvalidHash) {
+		t.Fatalf("expected ErrInvalidHash, got %v", err)
+	}
+}
+
+func TestArgon2idBounds(t *testing.T) {
+	t.Parallel()
+
+	// Too little memory for the lanes, or costs above the bounds
+	for _, opt := range []*Option{
+		testOption.SetMemory(16).SetParallelism(4),
+		testOption.SetMemory(maxMemory + 1),
+		testOption.SetIterations(maxIterations + 1),
+		testOption.SetParallelism(maxParallelism + 1),
+	} {
+		if _, err := Hash("correct horse", opt); err == nil {
+			t.Fatalf("expected an error for m=%d,t=%d,p=%d", opt.Memory(), opt.Iterations(), opt.Parallelism())
+		}
+	}
+
+	hash, err := Hash("correct horse", testOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt := strings.Split(hash, "$")[4:]
+	for _, cost := range []string{"m=4194304,t=1,p=1", "m=1024,t=1000,p=1", "m=1024,t=1,p=255", "m=16,t=1,p=4"} {
+		crafted := "$argon2id$v=19$" + cost + "$" + strings.Join(salt, "$")
+		if err := Verify("correct horse", crafted); !errors.Is(err, ErrInvalidHash) {
+			t.Fatalf("expected ErrInvalidHash for %s, got %v", cost, err)
+		}
+	}
+}
+
+func TestVerifyAndRehash(t *testing.T) {
+	t.Parallel()
+
+	hash, err := Hash("correct horse", testOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newHash, err := VerifyAndRehash("correct horse", hash, testOption); err != nil || newHash != "" {
+		t.Fatalf("up-to-date hash should not be rehashed: %q %v", newHash, err)
+	}
+
+	// Stronger parameters
+	stronger := testOption.SetIterations(2)
+	newHash, err := VerifyAndRehash("correct horse", hash, stronger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newHash == "" || NeedsRehash(newHash, stronger) {
+		t.Fatalf("expected a hash with the new parameters, got %q", newHash)
+	}
+
+	// bcrypt hashes are migrated to argon2id
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify("wrong horse", string(bcryptHash)); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+	newHash, err = VerifyAndRehash("correct horse", string(bcryptHash), testOption)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(newHash, prefixArgon2id) {
+		t.Fatalf("expected an argon2id hash, got %q", newHash)
+	}
+}
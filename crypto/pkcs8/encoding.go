@@ -0,0 +1,61 @@
+package pkcs8
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// EncryptPEM encrypts the DER of a PKCS#8 private key, see Encrypt,
+// and returns it in a PEM block of type "ENCRYPTED PRIVATE KEY".
+func EncryptPEM(der, passphrase []byte) ([]byte, error) {
+	bin, err := Encrypt(der, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  PEMType,
+		Bytes: bin,
+	}), nil
+}
+
+// EncryptBase64 encrypts the DER of a PKCS#8 private key, see Encrypt,
+// and returns the base64 of the EncryptedPrivateKeyInfo.
+func EncryptBase64(der, passphrase []byte) (string, error) {
+	bin, err := Encrypt(der, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+// DecryptPEM decrypts an "ENCRYPTED PRIVATE KEY" PEM block, e.g. the output of EncryptPEM
+// or "openssl pkcs8 -topk8 -v2 aes-256-cbc", and returns the DER of the PKCS#8 private key.
+func DecryptPEM(data, passphrase []byte) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+	if block.Type != PEMType {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	return decrypt(block.Bytes, passphrase)
+}
+
+// DecryptBase64 decrypts the base64 of an EncryptedPrivateKeyInfo, the output of EncryptBase64,
+// and returns the DER of the PKCS#8 private key.
+func DecryptBase64(secret string, passphrase []byte) ([]byte, error) {
+	bin, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return decrypt(bin, passphrase)
+}
+
+func decrypt(der, passphrase []byte) ([]byte, error) {
+	der, err := Decrypt(der, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	return der, nil
+}
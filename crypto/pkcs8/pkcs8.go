@@ -0,0 +1,280 @@
+// Package pkcs8 encrypts and decrypts PKCS#8 private keys with a passphrase (RFC 5958 EncryptedPrivateKeyInfo),
+// so the key files at rest are protected.
+//
+// The keys are encrypted with PBES2 (RFC 8018): PBKDF2 with HMAC-SHA256 and AES-256-CBC,
+// the format written by "openssl pkcs8 -topk8 -v2 aes-256-cbc" and read by the common tools.
+// The key packages (rsa, ed25519, ecdsa) use it for their encrypted PEM and secret forms:
+//
+//	passphrase, err := pkcs8.Passphrase("KEY_PASSPHRASE")
+//	// ...
+//	keyPair, err := rsa.KeyPairFromEncryptedPEM(data, passphrase)
+package pkcs8
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+)
+
+const (
+	// PEMType is the type of the PEM blocks of the encrypted keys
+	PEMType = "ENCRYPTED PRIVATE KEY"
+
+	// DefaultIterations is the PBKDF2 iteration count of the encrypted keys (OWASP recommendation for HMAC-SHA256)
+	DefaultIterations = 600_000
+
+	// maxIterations bounds the PBKDF2 iteration count read from an encrypted key,
+	// a crafted key cannot make Decrypt run for minutes
+	maxIterations = 10_000_000
+
+	saltSize = 16
+)
+
+var (
+	// ErrIncorrectPassphrase is returned when the key cannot be decrypted with the passphrase.
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+	// ErrUnsupportedAlgorithm is returned for an encryption scheme other than PBES2 with PBKDF2 and AES-CBC.
+	ErrUnsupportedAlgorithm = errors.New("unsupported key encryption algorithm")
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// encryptedPrivateKeyInfo is the EncryptedPrivateKeyInfo structure of RFC 5958.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params is the PBES2-params structure of RFC 8018.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params is the PBKDF2-params structure of RFC 8018, the PRF defaults to HMAC-SHA1.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// Encrypt encrypts the DER of a PKCS#8 private key (x509.MarshalPKCS8PrivateKey)
+// and returns the DER of the EncryptedPrivateKeyInfo.
+func Encrypt(der, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	salt := make([]byte, saltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, DefaultIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pad(der, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: DefaultIterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: data,
+	})
+}
+
+// Decrypt decrypts the DER of an EncryptedPrivateKeyInfo and returns the DER of the PKCS#8 private key,
+// to parse with x509.ParsePKCS8PrivateKey.
+// PBES2 with PBKDF2 (HMAC-SHA1/256/384/512) and AES-128/192/256-CBC is supported.
+func Decrypt(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("failed to parse encrypted private key: trailing data")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %w", err)
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: key derivation %s", ErrUnsupportedAlgorithm, params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %w", err)
+	}
+	if kdf.IterationCount < 1 || kdf.IterationCount > maxIterations {
+		return nil, fmt.Errorf("%w: iteration count %d", ErrUnsupportedAlgorithm, kdf.IterationCount)
+	}
+	prf, err := prfOf(kdf.PRF.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	keySize, err := keySizeOf(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != keySize {
+		return nil, fmt.Errorf("%w: key length %d", ErrUnsupportedAlgorithm, kdf.KeyLength)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse encryption parameters: %w", err)
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid initialization vector")
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid encrypted data size")
+	}
+
+	key, err := pbkdf2.Key(prf, string(passphrase), kdf.Salt, kdf.IterationCount, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(data, info.EncryptedData)
+	// A wrong passphrase is detected by the padding, or by the DER of the decrypted key
+	data, err = unpad(data, aes.BlockSize)
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	var raw asn1.RawValue
+	if rest, err := asn1.Unmarshal(data, &raw); err != nil || len(rest) > 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+	return data, nil
+}
+
+// Passphrase reads the passphrase from the environment variable name,
+// or from the file of the environment variable name + "_FILE" (e.g. a mounted secret).
+func Passphrase(name string) ([]byte, error) {
+	if val, ok := os.LookupEnv(name); ok && val != "" {
+		return []byte(val), nil
+	}
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		return PassphraseFromFile(path)
+	}
+	return nil, fmt.Errorf("passphrase is not set: %s or %s_FILE is required", name, name)
+}
+
+// PassphraseFromFile reads the passphrase from a file, the trailing newline is removed.
+func PassphraseFromFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	data = []byte(strings.TrimRight(string(data), "\r\n"))
+	if len(data) == 0 {
+		return nil, fmt.Errorf("passphrase file %s is empty", path)
+	}
+	return data, nil
+}
+
+func prfOf(oid asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(oid) == 0, oid.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case oid.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case oid.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case oid.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: PRF %s", ErrUnsupportedAlgorithm, oid)
+	}
+}
+
+func keySizeOf(oid asn1.ObjectIdentifier) (int, error) {
+	switch {
+	case oid.Equal(oidAES128CBC):
+		return 16, nil
+	case oid.Equal(oidAES192CBC):
+		return 24, nil
+	case oid.Equal(oidAES256CBC):
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("%w: cipher %s", ErrUnsupportedAlgorithm, oid)
+	}
+}
+
+// pad adds the PKCS#7 padding, the result is a copy.
+func pad(data []byte, size int) []byte {
+	n := size - len(data)%size
+	return append(bytes.Clone(data), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte, size int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > size || n > len(data) {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("invalid padding")
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,102 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+const (
+	// dataKeySize is the size of the AES-256 data key of the hybrid encryption
+	dataKeySize = 32
+)
+
+// EncryptOAEP encrypts the plaintext with RSA-OAEP, hash is one of crypto.SHA256, crypto.SHA384 or crypto.SHA512.
+// The plaintext must be shorter than the modulus size minus 2*hash size - 2 bytes (190 bytes for RSA 2048 and SHA-256),
+// use EncryptHybrid for larger payloads.
+func (p *KeyPair) EncryptOAEP(plaintext []byte, hash crypto.Hash, label []byte) ([]byte, error) {
+	return encryptOAEP(&p.PrivateKey.PublicKey, plaintext, hash, label)
+}
+
+// DecryptOAEP decrypts a ciphertext of EncryptOAEP, with the same hash and label.
+func (p *KeyPair) DecryptOAEP(ciphertext []byte, hash crypto.Hash, label []byte) ([]byte, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+	return rsa.DecryptOAEP(hash.New(), nil, p.PrivateKey, ciphertext, label)
+}
+
+func encryptOAEP(pub *rsa.PublicKey, plaintext []byte, hash crypto.Hash, label []byte) ([]byte, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+	return rsa.EncryptOAEP(hash.New(), rand.Reader, pub, plaintext, label)
+}
+
+// EncryptHybrid encrypts a payload of any size: a random AES-256-GCM data key encrypts the payload
+// and is wrapped with RSA-OAEP.
+//
+// Format: wrapped key (modulus size) || nonce (12 bytes) || AES-GCM ciphertext and tag.
+// The wrapped key is authenticated as additional data of AES-GCM.
+func (p *KeyPair) EncryptHybrid(plaintext []byte, hash crypto.Hash) ([]byte, error) {
+	return encryptHybrid(&p.PrivateKey.PublicKey, plaintext, hash)
+}
+
+// DecryptHybrid decrypts a ciphertext of EncryptHybrid, with the same hash.
+func (p *KeyPair) DecryptHybrid(ciphertext []byte, hash crypto.Hash) ([]byte, error) {
+	size := p.PrivateKey.Size()
+	if len(ciphertext) < size {
+		return nil, errors.New("ciphertext too short")
+	}
+	dataKey, err := p.DecryptOAEP(ciphertext[:size], hash, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	rest := ciphertext[size:]
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], ciphertext[:size])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+func encryptHybrid(pub *rsa.PublicKey, plaintext []byte, hash crypto.Hash) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	wrapped, err := encryptOAEP(pub, dataKey, hash, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(wrapped)+gcm.NonceSize(), len(wrapped)+gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	copy(out, wrapped)
+	nonce := out[len(wrapped):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(out, nonce, plaintext, wrapped), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
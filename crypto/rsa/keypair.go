@@ -27,7 +27,10 @@ func KeyPairFromSecret(secret string) (*KeyPair, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode secret: %w", err)
 	}
+	return keyPairFromPKCS8(der)
+}
 
+func keyPairFromPKCS8(der []byte) (*KeyPair, error) {
 	key, err := x509.ParsePKCS8PrivateKey(der)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
@@ -43,7 +46,7 @@ func KeyPairFromSecret(secret string) (*KeyPair, error) {
 
 func KeyPairFromPEM(privatePEM []byte) (*KeyPair, error) {
 	block, _ := pem.Decode(privatePEM)
-	if block == nil || block.Type != "PRIVATE KEY" {
+	if block == nil {
 		return nil, fmt.Errorf("failed to decode PEM block containing private key")
 	}
 
@@ -64,7 +67,7 @@ func KeyPairFromPEM(privatePEM []byte) (*KeyPair, error) {
 			return nil, fmt.Errorf("invalid private key")
 		}
 	case "RSA PRIVATE KEY":
-		// PKCS8 format
+		// PKCS1 format
 		keyParsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse private key: %w", err)
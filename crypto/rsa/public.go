@@ -0,0 +1,129 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+)
+
+// PublicKey is the public part of a KeyPair.
+// Verification services and encrypting parties use it without holding the private key.
+type PublicKey struct {
+	PublicKey *rsa.PublicKey
+}
+
+// ToPublic returns the public key of the key pair, to hand to the verification services.
+func (p *KeyPair) ToPublic() *PublicKey {
+	return &PublicKey{PublicKey: &p.PrivateKey.PublicKey}
+}
+
+// PublicKeyFromPKIX loads a public key from its base64 PKIX form, the output of KeyPair.PKIXPublicKey.
+func PublicKeyFromPKIX(pkix string) (*PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(pkix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return newPublicKey(key)
+}
+
+// PublicKeyFromPEM loads a public key from a PEM block:
+// "PUBLIC KEY" (PKIX), "RSA PUBLIC KEY" (PKCS1) or "CERTIFICATE" (X.509).
+func PublicKeyFromPEM(publicPEM []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(publicPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return newPublicKey(key)
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return &PublicKey{PublicKey: key}, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return PublicKeyFromCertificate(cert)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+}
+
+// PublicKeyFromCertificate returns the public key of a X.509 certificate.
+func PublicKeyFromCertificate(cert *x509.Certificate) (*PublicKey, error) {
+	return newPublicKey(cert.PublicKey)
+}
+
+func newPublicKey(key any) (*PublicKey, error) {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid public key: %T", key)
+	}
+	return &PublicKey{PublicKey: pub}, nil
+}
+
+func (p *PublicKey) PKIXPublicKey() (string, error) {
+	bin, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bin), nil
+}
+
+func (p *PublicKey) PEM() ([]byte, error) {
+	// PKIX marshal public key
+	pkix, err := x509.MarshalPKIXPublicKey(p.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pkix,
+	}), nil
+}
+
+// VerifyPKCS1v15 verifies a signature of KeyPair.SignPKCS1v15.
+func (p *PublicKey) VerifyPKCS1v15(data, signature []byte) error {
+	return verifyPKCS1v15(p.PublicKey, data, signature)
+}
+
+// VerifyPSS verifies a signature of KeyPair.SignPSS.
+func (p *PublicKey) VerifyPSS(data, signature []byte, hash crypto.Hash) error {
+	return verifyPSS(p.PublicKey, data, signature, hash)
+}
+
+// EncryptOAEP encrypts the plaintext for the key pair, see KeyPair.EncryptOAEP.
+func (p *PublicKey) EncryptOAEP(plaintext []byte, hash crypto.Hash, label []byte) ([]byte, error) {
+	return encryptOAEP(p.PublicKey, plaintext, hash, label)
+}
+
+// EncryptHybrid encrypts a payload of any size for the key pair, see KeyPair.EncryptHybrid.
+func (p *PublicKey) EncryptHybrid(plaintext []byte, hash crypto.Hash) ([]byte, error) {
+	return encryptHybrid(p.PublicKey, plaintext, hash)
+}
+
+func verifyPKCS1v15(pub *rsa.PublicKey, data, signature []byte) error {
+	// Compute SHA256 hash of the data
+	h := sha256.New()
+	h.Write(data)
+	d := h.Sum(nil)
+	// Verify the signature using RSA PKCS1v15
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, d, signature)
+}
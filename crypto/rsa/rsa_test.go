@@ -0,0 +1,164 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-devkit/pkg/crypto/pkcs8"
+)
+
+func TestPSSAndOAEP(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("transfer 100 from A to B")
+
+	for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		sig, err := keyPair.SignPSS(data, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := keyPair.VerifyPSS(data, sig, hash); err != nil {
+			t.Fatalf("%v: %v", hash, err)
+		}
+		if err := keyPair.VerifyPSS([]byte("tampered"), sig, hash); err == nil {
+			t.Fatalf("%v: expected an error for tampered data", hash)
+		}
+
+		ciphertext, err := keyPair.EncryptOAEP(data, hash, []byte("label"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err := keyPair.DecryptOAEP(ciphertext, hash, []byte("label"))
+		if err != nil || !bytes.Equal(plaintext, data) {
+			t.Fatalf("%v: unexpected plaintext %q, %v", hash, plaintext, err)
+		}
+	}
+
+	if _, err := keyPair.SignPSS(data, crypto.SHA1); err == nil {
+		t.Fatal("expected an error for SHA-1")
+	}
+
+	// Larger than the modulus
+	large := bytes.Repeat(data, 1000)
+	ciphertext, err := keyPair.EncryptHybrid(large, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := keyPair.DecryptHybrid(ciphertext, crypto.SHA256)
+	if err != nil || !bytes.Equal(plaintext, large) {
+		t.Fatalf("unexpected plaintext, %v", err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := keyPair.DecryptHybrid(ciphertext, crypto.SHA256); err == nil {
+		t.Fatal("expected an error for a tampered ciphertext")
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("statement.csv")
+	sig, err := keyPair.SignPSS(data, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkixKey, err := keyPair.PKIXPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM, _, err := keyPair.PEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "partner"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, keyPair.PrivateKey.Public(), keyPair.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	fromPKIX, err := PublicKeyFromPKIX(pkixKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPEM, err := PublicKeyFromPEM(publicPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromCert, err := PublicKeyFromPEM(certPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pub := range []*PublicKey{fromPKIX, fromPEM, fromCert} {
+		if err := pub.VerifyPSS(data, sig, crypto.SHA256); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ciphertext, err := fromPEM.EncryptHybrid(data, crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := keyPair.DecryptHybrid(ciphertext, crypto.SHA512); err != nil || !bytes.Equal(plaintext, data) {
+		t.Fatalf("unexpected plaintext %q, %v", plaintext, err)
+	}
+}
+
+func TestEncryptedKeyPair(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase := []byte("correct-horse")
+
+	_, privatePEM, err := keyPair.EncryptedPEM(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromPEM, err := KeyPairFromEncryptedPEM(privatePEM, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := keyPair.EncryptedPKCS8PrivateKey(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSecret, err := KeyPairFromEncryptedSecret(secret, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fromPEM.PrivateKey.Equal(keyPair.PrivateKey) || !fromSecret.PrivateKey.Equal(keyPair.PrivateKey) {
+		t.Fatal("loaded key does not match")
+	}
+
+	if _, err := KeyPairFromEncryptedPEM(privatePEM, []byte("battery-staple")); !errors.Is(err, pkcs8.ErrIncorrectPassphrase) {
+		t.Fatalf("expected incorrect passphrase, got %v", err)
+	}
+	if _, err := KeyPairFromPEM(privatePEM); err == nil {
+		t.Fatal("expected an error for an encrypted key")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"fmt"
 )
 
 func (p *KeyPair) SignPKCS1v15(data []byte) ([]byte, error) {
@@ -17,10 +18,54 @@ func (p *KeyPair) SignPKCS1v15(data []byte) ([]byte, error) {
 }
 
 func (p *KeyPair) VerifyPKCS1v15(data, signature []byte) error {
-	// Compute SHA256 hash of the data
-	h := sha256.New()
+	return verifyPKCS1v15(&p.PrivateKey.PublicKey, data, signature)
+}
+
+// SignPSS signs the data with RSA-PSS, hash is one of crypto.SHA256, crypto.SHA384 or crypto.SHA512.
+// The salt length equals the hash size.
+func (p *KeyPair) SignPSS(data []byte, hash crypto.Hash) ([]byte, error) {
+	d, err := digest(hash, data)
+	if err != nil {
+		return nil, err
+	}
+	// Sign the hash using RSA-PSS
+	return rsa.SignPSS(rand.Reader, p.PrivateKey, hash, d, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthEqualsHash,
+	})
+}
+
+// VerifyPSS verifies a RSA-PSS signature of the data made with the given hash.
+// Any salt length is accepted, to verify the signatures of other implementations.
+func (p *KeyPair) VerifyPSS(data, signature []byte, hash crypto.Hash) error {
+	return verifyPSS(&p.PrivateKey.PublicKey, data, signature, hash)
+}
+
+func verifyPSS(pub *rsa.PublicKey, data, signature []byte, hash crypto.Hash) error {
+	d, err := digest(hash, data)
+	if err != nil {
+		return err
+	}
+	// Verify the signature using RSA-PSS
+	return rsa.VerifyPSS(pub, hash, d, signature, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthAuto,
+	})
+}
+
+// digest computes the hash of the data, the hash must be SHA-256, SHA-384 or SHA-512.
+func digest(hash crypto.Hash, data []byte) ([]byte, error) {
+	if err := checkHash(hash); err != nil {
+		return nil, err
+	}
+	h := hash.New()
 	h.Write(data)
-	d := h.Sum(nil)
-	// Verify the signature using RSA PKCS1v15
-	return rsa.VerifyPKCS1v15(&p.PrivateKey.PublicKey, crypto.SHA256, d, signature)
+	return h.Sum(nil), nil
+}
+
+func checkHash(hash crypto.Hash) error {
+	switch hash {
+	case crypto.SHA256, crypto.SHA384, crypto.SHA512:
+		return nil
+	default:
+		return fmt.Errorf("unsupported hash: %v", hash)
+	}
 }
@@ -0,0 +1,40 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"io"
+
+	"github.com/golang-devkit/pkg/crypto/internal/thumbprint"
+)
+
+// Public returns the public key, the key pair is a crypto.Signer.
+func (p *KeyPair) Public() crypto.PublicKey {
+	return &p.PrivateKey.PublicKey
+}
+
+// Sign signs the digest as rsa.PrivateKey.Sign does (crypto.Signer):
+// PKCS #1 v1.5, or PSS when opts is a *rsa.PSSOptions.
+func (p *KeyPair) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return p.PrivateKey.Sign(rand, digest, opts)
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the default key ID of the jwt package.
+func (p *KeyPair) KeyID() string {
+	return keyID(&p.PrivateKey.PublicKey)
+}
+
+// Algorithm returns the JWA name (RFC 7518) of the signatures: RS256, the default algorithm of the RSA keys in the jwt package.
+func (p *KeyPair) Algorithm() string {
+	return "RS256"
+}
+
+// KeyID returns the RFC 7638 thumbprint of the public key, the key ID of the key pair.
+func (p *PublicKey) KeyID() string {
+	return keyID(p.PublicKey)
+}
+
+func keyID(pub *rsa.PublicKey) string {
+	kid, _ := thumbprint.Of(pub)
+	return kid
+}
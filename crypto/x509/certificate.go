@@ -0,0 +1,228 @@
+// Package x509 creates X.509 certificates and PKCS#10 certificate requests from the key pairs
+// of the rsa, ed25519 and ecdsa packages: self-signed certificates, a local development CA
+// and leaf certificates signed by the CA, e.g. to set up mTLS in tests without openssl:
+//
+//	ca, err := x509.NewCA(caKeyPair, x509.NewOption().SetCommonName("Development CA"))
+//	serverCert, err := ca.Issue(serverKeyPair, x509.NewOption().SetDNSNames("localhost"))
+//	tlsCert, err := x509.TLSCertificate(serverCert, serverKeyPair)
+//	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(x509.ServerTLSConfig(tlsCert, ca.CertPool()))))
+//
+// The keys are given as a KeyPair of the key packages or any crypto.Signer,
+// the public keys as a KeyPair, a PublicKey of the key packages or a crypto.PublicKey.
+package x509
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	defaultValidity   = 365 * 24 * time.Hour
+	defaultCAValidity = 10 * 365 * 24 * time.Hour
+	// clockSkew is subtracted from the default NotBefore, so a peer with a late clock accepts a new certificate
+	clockSkew = time.Minute
+
+	defaultCACommonName = "Development CA"
+)
+
+// serialLimit is the upper bound of the random serial numbers (128 bits)
+var serialLimit = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// SelfSigned creates a self-signed leaf certificate of the key, valid for the names of the options.
+//
+// Example:
+//
+//	cert, err := x509.SelfSigned(keyPair, x509.NewOption().SetDNSNames("localhost").SetIPAddresses(net.IPv6loopback))
+func SelfSigned(key any, opts ...*Option) (*x509.Certificate, error) {
+	signer, err := signerOf(key)
+	if err != nil {
+		return nil, err
+	}
+	template, err := leafTemplate(signer.Public(), mergeOption(opts))
+	if err != nil {
+		return nil, err
+	}
+	return create(template, template, signer.Public(), signer)
+}
+
+// CA is a certificate authority issuing the leaf certificates, e.g. a local development CA.
+type CA struct {
+	Certificate *x509.Certificate
+	signer      crypto.Signer
+}
+
+// NewCA creates a CA with a self-signed root certificate of the key.
+// The CA only signs leaf certificates (path length 0).
+func NewCA(key any, opts ...*Option) (*CA, error) {
+	signer, err := signerOf(key)
+	if err != nil {
+		return nil, err
+	}
+	opt := mergeOption(opts)
+	template, err := newTemplate(opt, defaultCAValidity)
+	if err != nil {
+		return nil, err
+	}
+	if template.Subject.CommonName == "" {
+		template.Subject.CommonName = defaultCACommonName
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = opt.keyUsage
+	if template.KeyUsage == 0 {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	cert, err := create(template, template, signer.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Certificate: cert, signer: signer}, nil
+}
+
+// LoadCA loads a CA from its certificate (PEM) and its key, e.g. the CA created by NewCA and stored with CA.PEM.
+func LoadCA(certPEM []byte, key any) (*CA, error) {
+	cert, err := ParseCertificatePEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsCA {
+		return nil, errors.New("certificate is not a CA")
+	}
+	signer, err := signerOf(key)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(signer.Public()) {
+		return nil, errors.New("key does not match the CA certificate")
+	}
+	return &CA{Certificate: cert, signer: signer}, nil
+}
+
+// Issue creates a leaf certificate of the public key signed by the CA.
+// The validity window is cut at the expiration of the CA.
+func (ca *CA) Issue(pub any, opts ...*Option) (*x509.Certificate, error) {
+	publicKey, err := publicKeyOf(pub)
+	if err != nil {
+		return nil, err
+	}
+	template, err := leafTemplate(publicKey, mergeOption(opts))
+	if err != nil {
+		return nil, err
+	}
+	return ca.sign(template, publicKey)
+}
+
+// PEM returns the CA certificate in a PEM block of type "CERTIFICATE".
+func (ca *CA) PEM() []byte {
+	return CertificatePEM(ca.Certificate)
+}
+
+// CertPool returns a pool with the CA certificate, to verify the peers (tls.Config RootCAs or ClientCAs).
+func (ca *CA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Certificate)
+	return pool
+}
+
+func (ca *CA) sign(template *x509.Certificate, pub crypto.PublicKey) (*x509.Certificate, error) {
+	if template.NotAfter.After(ca.Certificate.NotAfter) {
+		template.NotAfter = ca.Certificate.NotAfter
+	}
+	return create(template, ca.Certificate, pub, ca.signer)
+}
+
+// CertificatePEM encodes the certificate in a PEM block of type "CERTIFICATE".
+func CertificatePEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: cert.Raw,
+	})
+}
+
+// ParseCertificatePEM parses the first "CERTIFICATE" PEM block.
+func ParseCertificatePEM(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing certificate")
+	}
+	if block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
+// newTemplate returns a template with a random serial number, the subject, the names and the validity window of the option.
+func newTemplate(opt *Option, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, serialLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	notBefore := opt.notBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now().Add(-clockSkew)
+	}
+	if opt.validity > 0 {
+		validity = opt.validity
+	}
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   opt.commonName,
+			Organization: opt.organization,
+		},
+		DNSNames:       opt.dnsNames,
+		IPAddresses:    opt.ipAddresses,
+		URIs:           opt.uris,
+		EmailAddresses: opt.emailAddresses,
+		NotBefore:      notBefore,
+		NotAfter:       notBefore.Add(validity),
+	}, nil
+}
+
+func leafTemplate(pub crypto.PublicKey, opt *Option) (*x509.Certificate, error) {
+	template, err := newTemplate(opt, defaultValidity)
+	if err != nil {
+		return nil, err
+	}
+	template.BasicConstraintsValid = true
+	template.KeyUsage = opt.keyUsage
+	if template.KeyUsage == 0 {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		// RSA key exchange of TLS 1.2
+		if _, ok := pub.(*rsa.PublicKey); ok {
+			template.KeyUsage |= x509.KeyUsageKeyEncipherment
+		}
+	}
+	template.ExtKeyUsage = opt.extKeyUsage
+	if len(template.ExtKeyUsage) == 0 {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	}
+	return template, nil
+}
+
+func create(template, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,85 @@
+package x509
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// NewCSR creates a PKCS#10 certificate request of the key, with the subject and the names of the options.
+// The request is sent to a CA, e.g. CA.SignCSR.
+func NewCSR(key any, opts ...*Option) (*x509.CertificateRequest, error) {
+	signer, err := signerOf(key)
+	if err != nil {
+		return nil, err
+	}
+	opt := mergeOption(opts)
+	template, err := newTemplate(opt, 0)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        template.Subject,
+		DNSNames:       template.DNSNames,
+		IPAddresses:    template.IPAddresses,
+		URIs:           template.URIs,
+		EmailAddresses: template.EmailAddresses,
+	}, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+	return csr, nil
+}
+
+// SignCSR creates a leaf certificate for the certificate request, after checking its signature.
+// The subject and the names are taken from the request, unless they are set in the options;
+// the validity window and the key usages are taken from the options.
+func (ca *CA) SignCSR(csr *x509.CertificateRequest, opts ...*Option) (*x509.Certificate, error) {
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request: %w", err)
+	}
+	opt := mergeOption(opts)
+	template, err := leafTemplate(csr.PublicKey, opt)
+	if err != nil {
+		return nil, err
+	}
+	if opt.commonName == "" && len(opt.organization) == 0 {
+		template.Subject = csr.Subject
+	}
+	if len(opt.dnsNames) == 0 && len(opt.ipAddresses) == 0 && len(opt.uris) == 0 && len(opt.emailAddresses) == 0 {
+		template.DNSNames = csr.DNSNames
+		template.IPAddresses = csr.IPAddresses
+		template.URIs = csr.URIs
+		template.EmailAddresses = csr.EmailAddresses
+	}
+	return ca.sign(template, csr.PublicKey)
+}
+
+// CSRPEM encodes the certificate request in a PEM block of type "CERTIFICATE REQUEST".
+func CSRPEM(csr *x509.CertificateRequest) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE REQUEST",
+		Bytes: csr.Raw,
+	})
+}
+
+// ParseCSRPEM parses a "CERTIFICATE REQUEST" PEM block, e.g. the output of "openssl req -new".
+func ParseCSRPEM(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block containing certificate request")
+	}
+	if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("unsupported PEM block type: %s", block.Type)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+	return csr, nil
+}
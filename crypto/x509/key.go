@@ -0,0 +1,67 @@
+package x509
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+// signerOf returns the private key of a key pair, or the crypto.Signer.
+func signerOf(key any) (crypto.Signer, error) {
+	switch k := key.(type) {
+	case *rsaKeys.KeyPair:
+		if k == nil || k.PrivateKey == nil {
+			return nil, fmt.Errorf("key pair is nil")
+		}
+		return k.PrivateKey, nil
+	case *edKeys.KeyPair:
+		if k == nil || len(k.PrivateKey) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("invalid key pair")
+		}
+		return k.PrivateKey, nil
+	case *ecKeys.KeyPair:
+		if k == nil || k.PrivateKey == nil {
+			return nil, fmt.Errorf("key pair is nil")
+		}
+		return k.PrivateKey, nil
+	case crypto.Signer:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type: %T", key)
+	}
+}
+
+// publicKeyOf returns the public key of a key pair, of a public key of the key packages, or the crypto.PublicKey.
+func publicKeyOf(key any) (crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsaKeys.PublicKey:
+		if k == nil || k.PublicKey == nil {
+			return nil, fmt.Errorf("public key is nil")
+		}
+		return k.PublicKey, nil
+	case *edKeys.PublicKey:
+		if k == nil || len(k.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key")
+		}
+		return k.PublicKey, nil
+	case *ecKeys.PublicKey:
+		if k == nil || k.PublicKey == nil {
+			return nil, fmt.Errorf("public key is nil")
+		}
+		return k.PublicKey, nil
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return k, nil
+	default:
+		signer, err := signerOf(key)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported public key type: %T", key)
+		}
+		return signer.Public(), nil
+	}
+}
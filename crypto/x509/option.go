@@ -0,0 +1,155 @@
+package x509
+
+import (
+	"crypto/x509"
+	"net"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// NewOption returns the default option: valid from now for a year (ten years for a CA),
+// with the key usages of a TLS server and client.
+func NewOption() *Option {
+	return &Option{}
+}
+
+type Option struct {
+	// Subject
+	commonName   string
+	organization []string
+
+	// Subject alternative names
+	dnsNames       []string
+	ipAddresses    []net.IP
+	uris           []*url.URL
+	emailAddresses []string
+
+	// Validity window, zero means the default
+	notBefore time.Time
+	validity  time.Duration
+
+	// Key usages, zero means the default of the certificate kind
+	keyUsage    x509.KeyUsage
+	extKeyUsage []x509.ExtKeyUsage
+}
+
+func (src *Option) SetCommonName(name string) *Option {
+	dst := *src
+	dst.commonName = name
+	return &dst
+}
+
+func (src *Option) CommonName() string {
+	return src.commonName
+}
+
+func (src *Option) SetOrganization(organization ...string) *Option {
+	dst := *src
+	dst.organization = slices.Clone(organization)
+	return &dst
+}
+
+func (src *Option) Organization() []string {
+	return slices.Clone(src.organization)
+}
+
+// SetDNSNames sets the DNS names of the certificate, e.g. "localhost" or "api.example.com".
+func (src *Option) SetDNSNames(names ...string) *Option {
+	dst := *src
+	dst.dnsNames = slices.Clone(names)
+	return &dst
+}
+
+func (src *Option) DNSNames() []string {
+	return slices.Clone(src.dnsNames)
+}
+
+// SetIPAddresses sets the IP addresses of the certificate, e.g. net.IPv4(127, 0, 0, 1).
+func (src *Option) SetIPAddresses(ips ...net.IP) *Option {
+	dst := *src
+	dst.ipAddresses = slices.Clone(ips)
+	return &dst
+}
+
+func (src *Option) IPAddresses() []net.IP {
+	return slices.Clone(src.ipAddresses)
+}
+
+// SetURIs sets the URIs of the certificate, e.g. a SPIFFE ID "spiffe://example.com/payment".
+func (src *Option) SetURIs(uris ...*url.URL) *Option {
+	dst := *src
+	dst.uris = slices.Clone(uris)
+	return &dst
+}
+
+func (src *Option) URIs() []*url.URL {
+	return slices.Clone(src.uris)
+}
+
+func (src *Option) SetEmailAddresses(emails ...string) *Option {
+	dst := *src
+	dst.emailAddresses = slices.Clone(emails)
+	return &dst
+}
+
+func (src *Option) EmailAddresses() []string {
+	return slices.Clone(src.emailAddresses)
+}
+
+// SetNotBefore sets the start of the validity window, by default a minute ago to tolerate the clock skew.
+func (src *Option) SetNotBefore(t time.Time) *Option {
+	dst := *src
+	dst.notBefore = t
+	return &dst
+}
+
+func (src *Option) NotBefore() time.Time {
+	return src.notBefore
+}
+
+// SetValidity sets how long the certificate is valid from NotBefore.
+func (src *Option) SetValidity(d time.Duration) *Option {
+	dst := *src
+	dst.validity = d
+	return &dst
+}
+
+func (src *Option) Validity() time.Duration {
+	return src.validity
+}
+
+// SetKeyUsage replaces the default key usage:
+// x509.KeyUsageCertSign | x509.KeyUsageCRLSign for a CA, x509.KeyUsageDigitalSignature otherwise
+// (and x509.KeyUsageKeyEncipherment for a RSA key).
+func (src *Option) SetKeyUsage(usage x509.KeyUsage) *Option {
+	dst := *src
+	dst.keyUsage = usage
+	return &dst
+}
+
+func (src *Option) KeyUsage() x509.KeyUsage {
+	return src.keyUsage
+}
+
+// SetExtKeyUsage replaces the default extended key usages of the leaf certificates,
+// x509.ExtKeyUsageServerAuth and x509.ExtKeyUsageClientAuth.
+func (src *Option) SetExtKeyUsage(usages ...x509.ExtKeyUsage) *Option {
+	dst := *src
+	dst.extKeyUsage = slices.Clone(usages)
+	return &dst
+}
+
+func (src *Option) ExtKeyUsage() []x509.ExtKeyUsage {
+	return slices.Clone(src.extKeyUsage)
+}
+
+// mergeOption returns the first option, or the default option.
+func mergeOption(opts []*Option) *Option {
+	for _, op := range opts {
+		if op != nil {
+			return op
+		}
+	}
+	return NewOption()
+}
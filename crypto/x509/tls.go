@@ -0,0 +1,62 @@
+package x509
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// TLSCertificate returns the certificate with its key for tls.Config,
+// followed by the intermediate certificates of the chain if any.
+func TLSCertificate(cert *x509.Certificate, key any, chain ...*x509.Certificate) (tls.Certificate, error) {
+	signer, err := signerOf(key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tlsCert := tls.Certificate{
+		Certificate: [][]byte{cert.Raw},
+		PrivateKey:  signer,
+		Leaf:        cert,
+	}
+	for _, c := range chain {
+		tlsCert.Certificate = append(tlsCert.Certificate, c.Raw)
+	}
+	return tlsCert, nil
+}
+
+// ServerTLSConfig returns the TLS config of a server presenting the certificate.
+// When clientCAs is not nil, the clients must present a certificate signed by one of them (mTLS).
+//
+// Example:
+//
+//	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(x509.ServerTLSConfig(tlsCert, ca.CertPool()))))
+func ServerTLSConfig(cert tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAs != nil {
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config
+}
+
+// ClientTLSConfig returns the TLS config of a client trusting the rootCAs (the system roots when nil)
+// and presenting the certificate when not nil (mTLS).
+//
+// Example:
+//
+//	conn, err := net.NewCloudRunGRPCClient(target, net.CredentialOption{
+//		TransportCredentials:  credentials.NewTLS(x509.ClientTLSConfig(&tlsCert, ca.CertPool())),
+//		SkipPerRPCCredentials: true,
+//	})
+func ClientTLSConfig(cert *tls.Certificate, rootCAs *x509.CertPool) *tls.Config {
+	config := &tls.Config{
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	return config
+}
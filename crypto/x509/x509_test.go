@@ -0,0 +1,129 @@
+package x509
+
+import (
+	"crypto/elliptic"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	ecKeys "github.com/golang-devkit/pkg/crypto/ecdsa"
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+func TestMutualTLS(t *testing.T) {
+	t.Parallel()
+
+	caKey, err := ecKeys.GenerateKeyPair(elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientKey, err := rsaKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := NewCA(caKey, NewOption().SetValidity(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.Certificate.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+		t.Fatalf("unexpected CA key usage: %v", ca.Certificate.KeyUsage)
+	}
+	// The CA is reloaded from its stored certificate
+	if ca, err = LoadCA(ca.PEM(), caKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCA(ca.PEM(), serverKey); err == nil {
+		t.Fatal("expected an error for a key not matching the CA")
+	}
+
+	serverCert, err := ca.Issue(serverKey.ToPublic(), NewOption().
+		SetDNSNames("localhost").
+		SetIPAddresses(net.IPv4(127, 0, 0, 1)).
+		SetValidity(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverCert.NotAfter.After(ca.Certificate.NotAfter) {
+		t.Fatal("leaf certificate outlives the CA")
+	}
+
+	// The client certificate is issued from a certificate request
+	csr, err := NewCSR(clientKey, NewOption().SetCommonName("payment-client").SetDNSNames("payment.internal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csr, err = ParseCSRPEM(CSRPEM(csr)); err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := ca.SignCSR(csr, NewOption().SetExtKeyUsage(x509.ExtKeyUsageClientAuth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clientCert.Subject.CommonName != "payment-client" || clientCert.DNSNames[0] != "payment.internal" {
+		t.Fatalf("unexpected subject: %v %v", clientCert.Subject, clientCert.DNSNames)
+	}
+	if clientCert.KeyUsage&x509.KeyUsageKeyEncipherment == 0 {
+		t.Fatal("expected key encipherment usage for a RSA key")
+	}
+
+	serverTLS, err := TLSCertificate(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTLS, err := TLSCertificate(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConfig := ClientTLSConfig(&clientTLS, ca.CertPool())
+	clientConfig.ServerName = "localhost"
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	server := tls.Server(serverConn, ServerTLSConfig(serverTLS, ca.CertPool()))
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Handshake()
+	}()
+	if err := tls.Client(clientConn, clientConfig).Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if peer := server.ConnectionState().PeerCertificates; len(peer) == 0 || peer[0].Subject.CommonName != "payment-client" {
+		t.Fatal("client certificate is not verified by the server")
+	}
+}
+
+func TestSelfSigned(t *testing.T) {
+	t.Parallel()
+
+	keyPair, err := ecKeys.GenerateKeyPair(elliptic.P384())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := SelfSigned(keyPair, NewOption().SetCommonName("localhost").SetDNSNames("localhost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = ParseCertificatePEM(CertificatePEM(cert)); err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: pool}); err != nil {
+		t.Fatal(err)
+	}
+	if cert.IsCA {
+		t.Fatal("self-signed leaf certificate must not be a CA")
+	}
+}
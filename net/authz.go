@@ -0,0 +1,214 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+// AuthzRule is what a request must satisfy to be authorized.
+type AuthzRule struct {
+	// Scopes are the scopes required, all of them must be granted to the token.
+	Scopes []string
+	// Roles are the roles allowed, the token must have one of them (optional).
+	Roles []string
+	// Public serves the request without token.
+	// On HTTP, the route must be in JWTAuthOption.ExemptPaths too.
+	Public bool
+}
+
+// AuthzPolicy declares the authorization rules of the gRPC methods and HTTP routes.
+//
+// The keys of Rules are, from the most to the least specific:
+//   - a gRPC full method ("/payment.v1.PaymentService/Transfer"),
+//   - all the methods of a gRPC service ("/payment.v1.PaymentService/*"),
+//   - a HTTP method and mux route template ("DELETE /accounts/{id}"),
+//   - a mux route template, for any HTTP method ("/accounts/{id}").
+//
+// Example:
+//
+//	policy := net.AuthzPolicy{
+//		Rules: map[string]net.AuthzRule{
+//			"/grpc.health.v1.Health/*":            {Public: true},
+//			"/payment.v1.PaymentService/Transfer": {Scopes: []string{"payment:write"}},
+//			"/payment.v1.PaymentService/*":        {Scopes: []string{"payment:read"}},
+//			"GET /accounts/{id}":                  {Scopes: []string{"account:read"}},
+//			"/accounts/{id}":                      {Scopes: []string{"account:write"}, Roles: []string{"admin"}},
+//		},
+//		DenyUnmatched: true,
+//	}
+type AuthzPolicy struct {
+	Rules map[string]AuthzRule
+	// DenyUnmatched rejects the requests which match no rule,
+	// otherwise they are allowed for any valid token.
+	DenyUnmatched bool
+}
+
+// errNoAuthzRule is returned for a request which matches no rule when AuthzPolicy.DenyUnmatched is set.
+var errNoAuthzRule = errors.New("no authorization rule for this request")
+
+// ruleOf returns the rule of the first key found in Rules.
+func (p *AuthzPolicy) ruleOf(keys ...string) (AuthzRule, bool) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if rule, ok := p.Rules[key]; ok {
+			return rule, true
+		}
+	}
+	return AuthzRule{}, false
+}
+
+// grpcRule returns the rule of a gRPC full method.
+func (p *AuthzPolicy) grpcRule(fullMethod string) (AuthzRule, bool) {
+	service := ""
+	if i := strings.LastIndex(fullMethod, "/"); i > 0 {
+		service = fullMethod[:i] + "/*"
+	}
+	return p.ruleOf(fullMethod, service)
+}
+
+// httpRule returns the rule of the mux route of the request, or of its path when the route is unknown.
+func (p *AuthzPolicy) httpRule(r *http.Request) (AuthzRule, bool) {
+	template := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			template = tpl
+		}
+	}
+	return p.ruleOf(r.Method+" "+template, template)
+}
+
+// authorize checks the claims against the rule.
+func (p *AuthzPolicy) authorize(rule AuthzRule, matched bool, claims *jwt.MapClaims) error {
+	if !matched {
+		if p.DenyUnmatched {
+			return errNoAuthzRule
+		}
+		return nil
+	}
+	for _, scope := range rule.Scopes {
+		if !claims.HasScope(scope) {
+			return fmt.Errorf("missing required scope %q", scope)
+		}
+	}
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(rule.Roles, claims.HasRole) {
+		return fmt.Errorf("token has none of the roles %q", rule.Roles)
+	}
+	return nil
+}
+
+// UnaryServerPolicyInterceptor creates a server interceptor which authenticates the requests
+// with the bearer token of the "authorization" metadata, and authorizes them with the policy.
+// The claims of a valid token are applied to the context (see jwt.MapClaims.ApplyContext).
+// When the claims are already in the context, the request has been authenticated by a previous interceptor
+// and is only authorized.
+//
+// It returns codes.Unauthenticated when the token is missing or invalid,
+// and codes.PermissionDenied when the policy rejects the token.
+//
+// Example:
+//
+//	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+//		net.UnaryServerLoggingInterceptor(),
+//		net.UnaryServerPolicyInterceptor(jwt.PublicKeyVerifier(publicKey), policy),
+//	))
+func UnaryServerPolicyInterceptor(verifier jwt.Verifier, policy AuthzPolicy, opts ...*jwt.ParseOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		rule, matched := policy.grpcRule(info.FullMethod)
+		if matched && rule.Public {
+			return handler(ctx, req)
+		}
+		reqLogger := getLoggerFromContext(ctx).With(zap.String("method", info.FullMethod))
+
+		// Reuse the claims of a previous interceptor, e.g. UnaryServerDPoPInterceptor
+		claims := jwt.ClaimsFromContext(ctx)
+		if claims == nil {
+			_, jwtStr, _ := metadataFromContext(ctx, req)
+			if jwtStr == "" {
+				return nil, status.Error(codes.Unauthenticated, errMissingBearerToken.Error())
+			}
+			var err error
+			if claims, err = verifier.Verify(ctx, jwtStr, opts...); err != nil {
+				reqLogger.Warn("JWT authentication failed", zap.String(logger.KeyError, err.Error()))
+				return nil, status.Errorf(codes.Unauthenticated, "Authorization failed: %v", err)
+			}
+		}
+		// A token bound to a DPoP key is only accepted after UnaryServerDPoPInterceptor
+		if err := verifyDPoPBound(ctx, claims); err != nil {
+			reqLogger.Warn("JWT authentication failed", zap.String(logger.KeyError, err.Error()))
+			return nil, status.Errorf(codes.Unauthenticated, "Authorization failed: %v", err)
+		}
+		if err := policy.authorize(rule, matched, claims); err != nil {
+			reqLogger.Warn("Permission denied",
+				zap.String("user_id", claims.UserId),
+				zap.Strings("scopes", claims.Scopes),
+				zap.String(logger.KeyError, err.Error()))
+			return nil, status.Errorf(codes.PermissionDenied, "Permission denied: %v", err)
+		}
+		return handler(claims.ApplyContext(ctx), req)
+	}
+}
+
+// PolicyMiddleware creates a middleware which authorizes the requests with the policy.
+// It must be used after JWTAuthMiddleware, the claims are taken from the request context.
+//
+// It answers through WriteError with:
+//   - 401 Unauthorized when a rule matches the request but the request is not authenticated,
+//   - 403 Forbidden when the policy rejects the token.
+//
+// Example:
+//
+//	ro := mux.NewRouter()
+//	ro.Use(net.JWTAuthMiddleware(net.JWTAuthOption{PublicKey: publicKey}), net.PolicyMiddleware(policy))
+func PolicyMiddleware(policy AuthzPolicy) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				h.ServeHTTP(w, r)
+				return
+			}
+			rule, matched := policy.httpRule(r)
+			if matched && rule.Public {
+				h.ServeHTTP(w, r)
+				return
+			}
+			claims := jwt.ClaimsFromContext(r.Context())
+			if claims == nil {
+				// Exempted by JWTAuthMiddleware but not public
+				if matched || policy.DenyUnmatched {
+					writeUnauthorized(w, schemeBearer, errMissingBearerToken)
+					return
+				}
+				h.ServeHTTP(w, r)
+				return
+			}
+			if err := verifyDPoPBound(r.Context(), claims); err != nil {
+				writeUnauthorized(w, schemeBearer, err)
+				return
+			}
+			if err := policy.authorize(rule, matched, claims); err != nil {
+				getLoggerFromContext(r.Context()).Warn("Permission denied",
+					zap.String("user_id", claims.UserId),
+					zap.Strings("scopes", claims.Scopes),
+					zap.String(logger.KeyError, err.Error()))
+				WriteError(w, http.StatusForbidden, err)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package net
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+func TestAuthzPolicy(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(opt *jwt.Option) string {
+		str, err := jwt.SignWithClaims(key, nil, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+	reader := sign(jwt.NewOption().SetScopes("account:read", "payment:read"))
+	admin := sign(jwt.NewOption().SetScopes("account:read", "account:write", "payment:write").SetRoles("admin"))
+
+	policy := AuthzPolicy{
+		Rules: map[string]AuthzRule{
+			"/grpc.health.v1.Health/*":            {Public: true},
+			"/payment.v1.PaymentService/Transfer": {Scopes: []string{"payment:write"}},
+			"/payment.v1.PaymentService/*":        {Scopes: []string{"payment:read"}},
+			"GET /accounts/{id}":                  {Scopes: []string{"account:read"}},
+			"/accounts/{id}":                      {Scopes: []string{"account:write"}, Roles: []string{"admin"}},
+		},
+		DenyUnmatched: true,
+	}
+
+	t.Run("http", func(t *testing.T) {
+		ro := mux.NewRouter()
+		ro.HandleFunc("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		ro.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		ro.Use(JWTAuthMiddleware(JWTAuthOption{PublicKey: pub}), PolicyMiddleware(policy))
+
+		tests := []struct {
+			method, path, token string
+			status              int
+		}{
+			{method: http.MethodGet, path: "/accounts/1", token: reader, status: http.StatusNoContent},
+			{method: http.MethodDelete, path: "/accounts/1", token: reader, status: http.StatusForbidden},
+			{method: http.MethodDelete, path: "/accounts/1", token: admin, status: http.StatusNoContent},
+			{method: http.MethodGet, path: "/other", token: admin, status: http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(headerAuthorization, "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			ro.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("%s %s: expected status %d, got %d: %s", tt.method, tt.path, tt.status, rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		interceptor := UnaryServerPolicyInterceptor(jwt.PublicKeyVerifier(pub), policy)
+		handler := func(ctx context.Context, req any) (any, error) {
+			return jwt.ScopesFromContext(ctx), nil
+		}
+
+		tests := []struct {
+			method, token string
+			code          codes.Code
+		}{
+			{method: "/grpc.health.v1.Health/Check", code: codes.OK},
+			{method: "/payment.v1.PaymentService/Get", code: codes.Unauthenticated},
+			{method: "/payment.v1.PaymentService/Get", token: reader, code: codes.OK},
+			{method: "/payment.v1.PaymentService/Transfer", token: reader, code: codes.PermissionDenied},
+			{method: "/payment.v1.PaymentService/Transfer", token: admin, code: codes.OK},
+			{method: "/other.v1.OtherService/Get", token: admin, code: codes.PermissionDenied},
+		}
+		for _, tt := range tests {
+			ctx := context.Background()
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(headerAuthorization, "Bearer "+tt.token))
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("%s: expected code %s, got %v", tt.method, tt.code, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package net
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+// UnaryServerDPoPInterceptor creates a server interceptor which authenticates the requests
+// with a sender-constrained token (RFC 9449): the "authorization" metadata is "DPoP <token>"
+// and the "dpop" metadata is a proof signed by the key bound to the token.
+// The claims of a valid token are applied to the context (see jwt.MapClaims.ApplyContext).
+//
+// A gRPC call is a HTTP/2 POST request, so the proof is created for the method "POST"
+// and the URL "https://<authority><full method>":
+//
+//	proof, err := jwt.NewDPoPProof(key, http.MethodPost, "https://api.example.com/payment.v1.PaymentService/Transfer", accessToken)
+//	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "DPoP "+accessToken, "dpop", proof)
+//
+// Chain it before UnaryServerPolicyInterceptor to authorize the requests, the claims are then reused:
+//
+//	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+//		net.UnaryServerDPoPInterceptor(verifier, jwt.NewDPoPVerifier()),
+//		net.UnaryServerPolicyInterceptor(verifier, policy),
+//	))
+func UnaryServerDPoPInterceptor(verifier jwt.Verifier, dpop *jwt.DPoPVerifier, opts ...*jwt.ParseOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		reqLogger := getLoggerFromContext(ctx).With(zap.String("method", info.FullMethod))
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		jwtStr := authorizationToken(firstMetadata(md, headerAuthorization), schemeDPoP)
+		if jwtStr == "" {
+			return nil, status.Error(codes.Unauthenticated, errMissingBearerToken.Error())
+		}
+		claims, err := verifier.Verify(ctx, jwtStr, opts...)
+		if err != nil {
+			reqLogger.Warn("JWT authentication failed", zap.String(logger.KeyError, err.Error()))
+			return nil, status.Errorf(codes.Unauthenticated, "Authorization failed: %v", err)
+		}
+
+		authority := firstMetadata(md, ":authority")
+		if authority == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing :authority to verify the DPoP proof")
+		}
+		if err := verifyDPoP(ctx, dpop, firstMetadata(md, headerDPoP), jwt.DPoPRequest{
+			Method:      "POST",
+			URL:         "https://" + authority + info.FullMethod,
+			AccessToken: jwtStr,
+		}, claims); err != nil {
+			reqLogger.Warn("DPoP proof verification failed", zap.String(logger.KeyError, err.Error()))
+			return nil, status.Errorf(codes.Unauthenticated, "Authorization failed: %v", err)
+		}
+		return handler(claims.ApplyContext(withDPoPVerified(ctx)), req)
+	}
+}
+
+func firstMetadata(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package net
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+func TestUnaryServerDPoPInterceptor(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jkt, err := jwt.DPoPThumbprint(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := jwt.SignWithClaims(key, nil, jwt.NewOption().SetDPoPThumbprint(jkt).SetScopes("payment:read"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const method = "/payment.v1.PaymentService/Get"
+	verifier := jwt.PublicKeyVerifier(pub)
+	policy := AuthzPolicy{Rules: map[string]AuthzRule{"/payment.v1.PaymentService/*": {Scopes: []string{"payment:read"}}}}
+	dpop := UnaryServerDPoPInterceptor(verifier, jwt.NewDPoPVerifier())
+	authz := UnaryServerPolicyInterceptor(verifier, policy)
+	handler := func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	// DPoP then policy: the claims of the verified proof are reused
+	proof, err := jwt.NewDPoPProof(clientKey, http.MethodPost, "https://api.example.com"+method, accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		headerAuthorization, "DPoP "+accessToken, headerDPoP, proof, ":authority", "api.example.com"))
+	_, err = dpop(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		return authz(ctx, req, info, handler)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The bound token sent as a bearer token is rejected
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(headerAuthorization, "Bearer "+accessToken))
+	if _, err := authz(ctx, nil, info, handler); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected code %s for a bound bearer token, got %v", codes.Unauthenticated, err)
+	}
+
+	// Claims in the context without a verified proof are rejected too
+	claims, err := verifier.Verify(context.Background(), accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authz(claims.ApplyContext(context.Background()), nil, info, handler); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected code %s for unverified bound claims, got %v", codes.Unauthenticated, err)
+	}
+}
@@ -0,0 +1,85 @@
+package net
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+func TestIntegrityInterceptors(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const transfer = "/payment.v1.PaymentService/Transfer"
+	req, err := structpb.NewStruct(map[string]any{"from": "A", "to": "B", "amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The client interceptor signs the request, the outgoing metadata is captured by the invoker
+	var md metadata.MD
+	client := UnaryClientIntegrityInterceptor(key, jwt.NewOption().SetUserId("alice"))
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	if err := client(context.Background(), transfer, req, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+
+	server := UnaryServerIntegrityInterceptor(jwt.PublicKeyVerifier(pub))
+	handler := func(ctx context.Context, req any) (any, error) {
+		return jwt.UserIdFromContext(ctx), nil
+	}
+	tampered, err := structpb.NewStruct(map[string]any{"from": "A", "to": "C", "amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		md     metadata.MD
+		method string
+		req    any
+		code   codes.Code
+	}{
+		{name: "valid", md: md, method: transfer, req: req, code: codes.OK},
+		{name: "missing token", method: transfer, req: req, code: codes.Unauthenticated},
+		{name: "tampered request", md: md, method: transfer, req: tampered, code: codes.Unauthenticated},
+		{name: "other method", md: md, method: "/payment.v1.PaymentService/Refund", req: req, code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			resp, err := server(ctx, tt.req, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("expected code %s, got %v", tt.code, err)
+			}
+			if err == nil && resp != "alice" {
+				t.Fatalf("expected the claims in the context, got %v", resp)
+			}
+		})
+	}
+
+	// A token bound to a DPoP key is not accepted without a proof
+	bound := UnaryClientIntegrityInterceptor(key, jwt.NewOption().SetDPoPThumbprint("thumbprint"))
+	if err := bound(context.Background(), transfer, req, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	if _, err := server(ctx, req, &grpc.UnaryServerInfo{FullMethod: transfer}, handler); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected code %s for a bound token, got %v", codes.Unauthenticated, err)
+	}
+}
@@ -17,11 +17,18 @@ const (
 	headerContentType   string = "Content-Type"
 	headerAuthorization string = "Authorization"
 	headerContentLength string = "Content-Length"
+	headerDPoP          string = "DPoP"
+	headerWWWAuth       string = "WWW-Authenticate"
+
+	// Authorization schemes
+	schemeBearer string = "Bearer"
+	schemeDPoP   string = "DPoP"
 
 	// Custom request headers
 	xApiClientId       string = "X-Api-Client-Id"
 	xApiRequestId      string = "X-Api-Request-Id"
 	xApiServiceAccount string = "X-Api-Service-Account"
+	xForwardedProto    string = "X-Forwarded-Proto"
 
 	// Custom response headers
 	xDescription      string = "X-Description"
@@ -0,0 +1,240 @@
+package net
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+var (
+	errMissingBearerToken = errors.New("missing bearer token")
+	errDPoPBoundToken     = errors.New("token is bound to a DPoP key and requires a DPoP proof")
+)
+
+type dpopContextKey struct{}
+
+// JWTAuthOption configures JWTAuthMiddleware.
+type JWTAuthOption struct {
+	// Verifier verifies the bearer token (e.g. *jwt.JWKSVerifier or *jwt.Keyring).
+	// If nil, the token is verified with jwt.ParseClaims and PublicKey.
+	Verifier jwt.Verifier
+	// PublicKey is used to verify the token when Verifier is nil.
+	PublicKey crypto.PublicKey
+	// ParseOptions are passed to the verifier, e.g. the expected issuer or a revocation store.
+	ParseOptions []*jwt.ParseOption
+
+	// ExemptPaths are served without authentication.
+	// An entry matches the request path or the mux route template exactly,
+	// or every path below it when it ends with "/" (e.g. "/public/").
+	ExemptPaths []string
+
+	// RequiredAudience rejects with 403 the tokens which contain none of these audiences.
+	RequiredAudience []string
+	// RequiredClaims rejects with 403 the tokens for which it returns an error.
+	RequiredClaims func(claims *jwt.MapClaims) error
+
+	// DPoP requires sender-constrained tokens (RFC 9449): the token is sent as "Authorization: DPoP <token>",
+	// with a proof signed by the key bound to the token in the "DPoP" header.
+	// If nil, the tokens are bearer tokens.
+	DPoP *jwt.DPoPVerifier
+}
+
+func (opt *JWTAuthOption) verifier() (jwt.Verifier, error) {
+	if opt.Verifier != nil {
+		return opt.Verifier, nil
+	}
+	if opt.PublicKey != nil {
+		return jwt.PublicKeyVerifier(opt.PublicKey), nil
+	}
+	return nil, errors.New("either Verifier or PublicKey is required")
+}
+
+func (opt *JWTAuthOption) exempt(r *http.Request) bool {
+	return exemptPath(r, opt.ExemptPaths)
+}
+
+// exemptPath reports whether the request path or its mux route template matches one of the paths,
+// a path ending with "/" matches every path below it.
+func exemptPath(r *http.Request, paths []string) bool {
+	var template string
+	if route := mux.CurrentRoute(r); route != nil {
+		template, _ = route.GetPathTemplate()
+	}
+	for _, path := range paths {
+		if path == r.URL.Path || (template != "" && path == template) {
+			return true
+		}
+		if strings.HasSuffix(path, "/") && strings.HasPrefix(r.URL.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (opt *JWTAuthOption) scheme() string {
+	if opt.DPoP != nil {
+		return schemeDPoP
+	}
+	return schemeBearer
+}
+
+// BearerToken returns the token of the "Authorization: Bearer <token>" header, or an empty string.
+func BearerToken(r *http.Request) string {
+	return authorizationToken(r.Header.Get(headerAuthorization), schemeBearer)
+}
+
+// DPoPToken returns the token of the "Authorization: DPoP <token>" header, or an empty string.
+func DPoPToken(r *http.Request) string {
+	return authorizationToken(r.Header.Get(headerAuthorization), schemeDPoP)
+}
+
+// authorizationToken returns the token of the authorization value if it uses the given scheme.
+func authorizationToken(auth, scheme string) string {
+	if len(auth) > len(scheme)+1 && strings.EqualFold(auth[:len(scheme)+1], scheme+" ") {
+		return strings.TrimSpace(auth[len(scheme)+1:])
+	}
+	return ""
+}
+
+// requestURL returns the URL the client sent the request to, without query.
+// The scheme is taken from the X-Forwarded-Proto header behind a proxy (e.g. Cloud Run).
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get(xForwardedProto), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.EscapedPath()
+}
+
+// JWTAuthMiddleware creates a middleware which authenticates the requests with a JWT bearer token.
+// The claims of a valid token are applied to the request context (see jwt.MapClaims.ApplyContext).
+//
+// It answers through WriteError with:
+//   - 401 Unauthorized when the token is missing or invalid, or when the DPoP proof is invalid,
+//     a token bound to a DPoP key ("cnf" claim) is rejected when DPoP is nil,
+//   - 403 Forbidden when the token is valid but fails RequiredAudience or RequiredClaims,
+//   - 500 Internal Server Error when the option has neither Verifier nor PublicKey.
+//
+// Example:
+//
+//	ro := mux.NewRouter()
+//	ro.Use(net.JWTAuthMiddleware(net.JWTAuthOption{
+//		PublicKey:        keyPair.PublicKey,
+//		ExemptPaths:      []string{"/healthz", "/public/"},
+//		RequiredAudience: []string{"PAYMENT"},
+//	}))
+//	handler := net.Middleware(ro, true)
+func JWTAuthMiddleware(opt JWTAuthOption) mux.MiddlewareFunc {
+	verifier, verifierErr := opt.verifier()
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A misconfigured middleware rejects every request rather than serving them unauthenticated
+			if verifierErr != nil {
+				getLoggerFromContext(r.Context()).Error("JWT authentication is misconfigured",
+					zap.String(logger.KeyError, verifierErr.Error()))
+				WriteError(w, http.StatusInternalServerError, verifierErr)
+				return
+			}
+			// Preflight requests and exempted routes are not authenticated
+			if r.Method == http.MethodOptions || opt.exempt(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			reqLogger := getLoggerFromContext(r.Context())
+
+			scheme := opt.scheme()
+			jwtStr := authorizationToken(r.Header.Get(headerAuthorization), scheme)
+			if jwtStr == "" {
+				writeUnauthorized(w, scheme, errMissingBearerToken)
+				return
+			}
+			claims, err := verifier.Verify(r.Context(), jwtStr, opt.ParseOptions...)
+			if err != nil {
+				reqLogger.Warn("JWT authentication failed", zap.String(logger.KeyError, err.Error()))
+				writeUnauthorized(w, scheme, err)
+				return
+			}
+			if opt.DPoP != nil {
+				if err := verifyDPoP(r.Context(), opt.DPoP, r.Header.Get(headerDPoP), jwt.DPoPRequest{
+					Method:      r.Method,
+					URL:         requestURL(r),
+					AccessToken: jwtStr,
+				}, claims); err != nil {
+					reqLogger.Warn("DPoP proof verification failed", zap.String(logger.KeyError, err.Error()))
+					writeUnauthorized(w, scheme, err)
+					return
+				}
+				r = r.WithContext(withDPoPVerified(r.Context()))
+			}
+			if err := verifyDPoPBound(r.Context(), claims); err != nil {
+				reqLogger.Warn("JWT authentication failed", zap.String(logger.KeyError, err.Error()))
+				writeUnauthorized(w, scheme, err)
+				return
+			}
+
+			if len(opt.RequiredAudience) > 0 && !slices.ContainsFunc(opt.RequiredAudience, func(aud string) bool {
+				return slices.Contains(claims.Audience, aud)
+			}) {
+				reqLogger.Warn("JWT audience is not allowed", zap.Strings("audience", claims.Audience))
+				WriteError(w, http.StatusForbidden, fmt.Errorf("token audience is not allowed"))
+				return
+			}
+			if opt.RequiredClaims != nil {
+				if err := opt.RequiredClaims(claims); err != nil {
+					reqLogger.Warn("JWT required claims failed", zap.String(logger.KeyError, err.Error()))
+					WriteError(w, http.StatusForbidden, err)
+					return
+				}
+			}
+
+			h.ServeHTTP(w, r.WithContext(claims.ApplyContext(r.Context())))
+		})
+	}
+}
+
+// verifyDPoP verifies the DPoP proof of the request and its binding to the token.
+func verifyDPoP(ctx context.Context, dpop *jwt.DPoPVerifier, proof string, req jwt.DPoPRequest, claims *jwt.MapClaims) error {
+	jkt, err := dpop.Verify(ctx, proof, req)
+	if err != nil {
+		return err
+	}
+	return claims.VerifyDPoPBinding(jkt)
+}
+
+// withDPoPVerified marks the context of a request whose DPoP proof has been verified.
+func withDPoPVerified(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dpopContextKey{}, true)
+}
+
+// verifyDPoPBound rejects a token bound to a DPoP key unless the DPoP proof of the request has been verified,
+// so that a leaked sender-constrained token can not be downgraded to a bearer token (RFC 9449, section 7.2).
+func verifyDPoPBound(ctx context.Context, claims *jwt.MapClaims) error {
+	if verified, _ := ctx.Value(dpopContextKey{}).(bool); claims.Confirmation != nil && !verified {
+		return errDPoPBoundToken
+	}
+	return nil
+}
+
+func writeUnauthorized(w http.ResponseWriter, scheme string, err error) {
+	switch {
+	case errors.Is(err, errMissingBearerToken):
+		// RFC 6750, section 3: no error code when the request lacks any authentication information
+		w.Header().Set(headerWWWAuth, scheme)
+	case errors.Is(err, jwt.ErrDPoPProofInvalid), errors.Is(err, jwt.ErrDPoPProofReplayed):
+		// RFC 9449, section 7.1
+		w.Header().Set(headerWWWAuth, scheme+` error="invalid_dpop_proof"`)
+	default:
+		w.Header().Set(headerWWWAuth, scheme+` error="invalid_token"`)
+	}
+	WriteError(w, http.StatusUnauthorized, err)
+}
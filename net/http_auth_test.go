@@ -0,0 +1,158 @@
+package net
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+)
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ro := mux.NewRouter()
+	ro.HandleFunc("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_ = WriteJSON(w, http.StatusOK, map[string]string{"userId": jwt.UserIdFromContext(r.Context())})
+	})
+	ro.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ro.Use(JWTAuthMiddleware(JWTAuthOption{
+		PublicKey:        pub,
+		ExemptPaths:      []string{"/healthz"},
+		RequiredAudience: []string{"PAYMENT"},
+		RequiredClaims: func(claims *jwt.MapClaims) error {
+			if claims.UserId == "" {
+				return errors.New("userId is required")
+			}
+			return nil
+		},
+	}))
+
+	sign := func(opt *jwt.Option) string {
+		str, err := jwt.SignWithClaims(key, nil, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return str
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "exempt", path: "/healthz", status: http.StatusNoContent},
+		{name: "missing token", path: "/accounts/1", status: http.StatusUnauthorized},
+		{name: "invalid token", path: "/accounts/1", token: "invalid", status: http.StatusUnauthorized},
+		{name: "wrong audience", path: "/accounts/1", token: sign(jwt.NewOption().SetUserId("u-1")), status: http.StatusForbidden},
+		{name: "missing claim", path: "/accounts/1", token: sign(jwt.NewOption().SetAudience("PAYMENT")), status: http.StatusForbidden},
+		{name: "ok", path: "/accounts/1", token: sign(jwt.NewOption().SetAudience("PAYMENT").SetUserId("u-1")), status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set(headerAuthorization, "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			ro.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTAuthMiddlewareMisconfigured(t *testing.T) {
+	t.Parallel()
+
+	handler := JWTAuthMiddleware(JWTAuthOption{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("the request must not be served")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/accounts/1", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func TestJWTAuthMiddlewareDPoP(t *testing.T) {
+	t.Parallel()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, clientKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jkt, err := jwt.DPoPThumbprint(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessToken, err := jwt.SignWithClaims(key, nil, jwt.NewOption().SetDPoPThumbprint(jkt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ro := mux.NewRouter()
+	ro.HandleFunc("/accounts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ro.Use(JWTAuthMiddleware(JWTAuthOption{PublicKey: pub, DPoP: jwt.NewDPoPVerifier()}))
+
+	serve := func(scheme, proof string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "https://api.example.com/accounts/1", nil)
+		req.Header.Set(headerAuthorization, scheme+" "+accessToken)
+		if proof != "" {
+			req.Header.Set(headerDPoP, proof)
+		}
+		rec := httptest.NewRecorder()
+		ro.ServeHTTP(rec, req)
+		return rec
+	}
+
+	proof, err := jwt.NewDPoPProof(clientKey, http.MethodGet, "https://api.example.com/accounts/1", accessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(schemeBearer, proof); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for a bearer token, got %d", rec.Code)
+	}
+	if rec := serve(schemeDPoP, ""); rec.Code != http.StatusUnauthorized ||
+		rec.Header().Get(headerWWWAuth) != `DPoP error="invalid_dpop_proof"` {
+		t.Fatalf("expected invalid proof, got %d %q", rec.Code, rec.Header().Get(headerWWWAuth))
+	}
+	if rec := serve(schemeDPoP, proof); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(schemeDPoP, proof); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for a replayed proof, got %d", rec.Code)
+	}
+
+	// A bound token is not downgraded to a bearer token by a middleware without DPoP
+	bearer := JWTAuthMiddleware(JWTAuthOption{PublicKey: pub})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/accounts/1", nil)
+	req.Header.Set(headerAuthorization, schemeBearer+" "+accessToken)
+	rec := httptest.NewRecorder()
+	bearer.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get(headerWWWAuth) != `Bearer error="invalid_token"` {
+		t.Fatalf("expected status 401 for a bound bearer token, got %d %q", rec.Code, rec.Header().Get(headerWWWAuth))
+	}
+}
@@ -0,0 +1,762 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+const (
+	headerSignature      string = "Signature"
+	headerSignatureInput string = "Signature-Input"
+	headerContentDigest  string = "Content-Digest"
+
+	// Algorithms of the HTTP message signatures (RFC 9421, section 3.3)
+	SignatureAlgEd25519      = "ed25519"
+	SignatureAlgRSAPSSSHA512 = "rsa-pss-sha512"
+	SignatureAlgHMACSHA256   = "hmac-sha256"
+
+	// signatureLabel is the label of the signatures created by HTTPSigner
+	signatureLabel = "sig1"
+
+	// signatureLeeway is the clock skew tolerated on the "created" and "expires" parameters
+	signatureLeeway = 5 * time.Second
+)
+
+var (
+	// ErrHTTPSignatureInvalid is returned when the signature of a request is missing, malformed or does not verify.
+	ErrHTTPSignatureInvalid = errors.New("invalid HTTP message signature")
+
+	errSignatureKeysRequired = errors.New("verification keys are required")
+
+	// defaultSignatureComponents are covered by the signatures, the "content-digest" is added when the request has a body.
+	// The "@authority" binds the signature to the host, it cannot be replayed against another service sharing the key.
+	defaultSignatureComponents = []string{"@method", "@authority", "@path", "@query"}
+)
+
+type signatureContextKey string
+
+// SignatureClientIdKey is the key of the client ID of a verified signature in the context
+const SignatureClientIdKey signatureContextKey = "clientIdOfSignature"
+
+// SignatureClientIdFromContext returns the client ID (key ID) of the signature verified by HTTPSignatureMiddleware, or an empty string.
+func SignatureClientIdFromContext(ctx context.Context) string {
+	if val, ok := ctx.Value(SignatureClientIdKey).(string); ok {
+		return val
+	}
+	return ""
+}
+
+// HTTPSigner signs the outgoing requests with RFC 9421 HTTP message signatures.
+// The key ID is the client ID of the caller, sent in the X-Api-Client-Id header.
+//
+// The signature covers the method, the authority (host), the path, the query, the X-Api-Client-Id header,
+// the Content-Digest header (RFC 9530) of the body if any, and the headers set with SetHeaders.
+// Each signature has a random "nonce" parameter, checked by the servers with a replay cache (see HTTPSignatureOption.Nonces).
+//
+// Example:
+//
+//	signer, err := net.NewHTTPSigner("partner-01", keyPair)
+//	client := &http.Client{Transport: signer.Transport(http.DefaultTransport)}
+type HTTPSigner struct {
+	keyId   string
+	alg     string
+	key     any
+	headers []string
+	now     func() time.Time
+}
+
+// NewHTTPSigner creates a signer with the key:
+//   - ed25519.PrivateKey, *ed25519.KeyPair or a crypto.Signer of an Ed25519 key: ed25519
+//   - *rsa.PrivateKey, *rsa.KeyPair or a crypto.Signer of a RSA key: rsa-pss-sha512
+//   - []byte, the secret shared with the server: hmac-sha256
+func NewHTTPSigner(keyId string, key any) (*HTTPSigner, error) {
+	if keyId == "" {
+		return nil, errors.New("key ID is required")
+	}
+	alg, err := signatureAlgorithmOf(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := key.(crypto.Signer); !ok && alg != SignatureAlgHMACSHA256 {
+		return nil, fmt.Errorf("private key is required, got %T", key)
+	}
+	return &HTTPSigner{keyId: keyId, alg: alg, key: key, now: time.Now}, nil
+}
+
+// SetHeaders adds the headers to the components covered by the signature, e.g. "Authorization" or "X-Api-Request-Id".
+// The requests must have these headers.
+func (src *HTTPSigner) SetHeaders(headers ...string) *HTTPSigner {
+	dst := *src
+	dst.headers = slices.Clone(headers)
+	return &dst
+}
+
+func (src *HTTPSigner) Headers() []string {
+	return slices.Clone(src.headers)
+}
+
+func (src *HTTPSigner) KeyId() string {
+	return src.keyId
+}
+
+func (src *HTTPSigner) Algorithm() string {
+	return src.alg
+}
+
+// Sign sets the Content-Digest, Signature-Input and Signature headers of the request.
+// The X-Api-Client-Id header is set to the key ID when it is missing.
+func (s *HTTPSigner) Sign(r *http.Request) error {
+	if r.Header.Get(xApiClientId) == "" {
+		r.Header.Set(xApiClientId, s.keyId)
+	}
+
+	components := slices.Concat(defaultSignatureComponents, []string{strings.ToLower(xApiClientId)})
+	body, err := readRequestBody(r)
+	if err != nil {
+		return err
+	}
+	if len(body) > 0 {
+		r.Header.Set(headerContentDigest, contentDigest(body))
+		components = append(components, strings.ToLower(headerContentDigest))
+	}
+	for _, h := range s.headers {
+		if name := strings.ToLower(h); !slices.Contains(components, name) {
+			components = append(components, name)
+		}
+	}
+
+	params := signatureParams{
+		components: components,
+		params: [][2]string{
+			{"created", strconv.FormatInt(s.now().Unix(), 10)},
+			{"nonce", strconv.Quote(rand.Text())},
+			{"keyid", strconv.Quote(s.keyId)},
+			{"alg", strconv.Quote(s.alg)},
+		},
+	}
+	base, err := signatureBase(r, params)
+	if err != nil {
+		return err
+	}
+	sig, err := signMessage(s.alg, s.key, base)
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set(headerSignatureInput, signatureLabel+"="+params.String())
+	r.Header.Set(headerSignature, signatureLabel+"=:"+base64.StdEncoding.EncodeToString(sig)+":")
+	return nil
+}
+
+// Transport returns a http.RoundTripper which signs the requests before sending them with base
+// (http.DefaultTransport when nil).
+func (s *HTTPSigner) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		// A RoundTripper must not modify the request
+		r = r.Clone(r.Context())
+		if err := s.Sign(r); err != nil {
+			return nil, err
+		}
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// HTTPSignatureKeyFunc returns the verification key of the client: ed25519.PublicKey, *rsa.PublicKey,
+// a PublicKey of the ed25519 and rsa packages of this module, or the secret ([]byte) of a hmac-sha256 client.
+type HTTPSignatureKeyFunc func(ctx context.Context, clientId string) (any, error)
+
+// HTTPSignatureOption configures HTTPSignatureMiddleware.
+type HTTPSignatureOption struct {
+	// Keys looks up the verification key of the client ID, the key ID of the signature.
+	Keys HTTPSignatureKeyFunc
+
+	// RequiredHeaders must be covered by the signature, in addition to the method, the authority, the path,
+	// the query, the Content-Digest of a request with a body and the X-Api-Client-Id header when present.
+	RequiredHeaders []string
+
+	// Nonces, when set, requires the "nonce" parameter and rejects a nonce already used by the client,
+	// e.g. jwt.NewMemoryReplayCache(). Without it a signature can be replayed until MaxAge.
+	Nonces jwt.ReplayCache
+
+	// MaxAge is how long after its creation a signature is accepted, 5 minutes by default.
+	MaxAge time.Duration
+
+	// MaxBodySize is the size limit of the body read to check its Content-Digest, 4 MiB by default.
+	// A larger request is rejected with 413 Request Entity Too Large.
+	MaxBodySize int64
+
+	// ExemptPaths are served without verification, as in JWTAuthOption.
+	ExemptPaths []string
+}
+
+func (opt *HTTPSignatureOption) maxAge() time.Duration {
+	if opt.MaxAge > 0 {
+		return opt.MaxAge
+	}
+	return 5 * time.Minute
+}
+
+func (opt *HTTPSignatureOption) maxBodySize() int64 {
+	if opt.MaxBodySize > 0 {
+		return opt.MaxBodySize
+	}
+	return 4 << 20
+}
+
+// HTTPSignatureMiddleware creates a middleware which verifies the RFC 9421 HTTP message signature of the requests,
+// e.g. created by HTTPSigner. The client ID (key ID of the signature) is applied to the request context,
+// see SignatureClientIdFromContext.
+//
+// It answers through WriteError with:
+//   - 401 Unauthorized when the signature is missing or invalid, or when the Content-Digest does not match the body,
+//   - 413 Request Entity Too Large when the body is larger than MaxBodySize,
+//   - 500 Internal Server Error when the option has no Keys.
+//
+// Example:
+//
+//	ro := mux.NewRouter()
+//	ro.Use(net.HTTPSignatureMiddleware(net.HTTPSignatureOption{
+//		Keys: func(ctx context.Context, clientId string) (any, error) {
+//			return partners.PublicKey(ctx, clientId)
+//		},
+//	}))
+//	handler := net.Middleware(ro, true)
+func HTTPSignatureMiddleware(opt HTTPSignatureOption) mux.MiddlewareFunc {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A misconfigured middleware rejects every request rather than serving them unverified
+			if opt.Keys == nil {
+				getLoggerFromContext(r.Context()).Error("HTTP signature verification is misconfigured",
+					zap.String(logger.KeyError, errSignatureKeysRequired.Error()))
+				WriteError(w, http.StatusInternalServerError, errSignatureKeysRequired)
+				return
+			}
+			// Preflight requests and exempted routes are not verified
+			if r.Method == http.MethodOptions || exemptPath(r, opt.ExemptPaths) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			clientId, err := VerifyHTTPSignature(r, opt)
+			if err != nil {
+				getLoggerFromContext(r.Context()).Warn("HTTP signature verification failed",
+					zap.String(logger.KeyNetClientID, r.Header.Get(xApiClientId)),
+					zap.String(logger.KeyError, err.Error()))
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					WriteError(w, http.StatusRequestEntityTooLarge, err)
+					return
+				}
+				WriteError(w, http.StatusUnauthorized, err)
+				return
+			}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), SignatureClientIdKey, clientId)))
+		})
+	}
+}
+
+// VerifyHTTPSignature verifies the HTTP message signature of the request and returns the client ID (key ID).
+// The body of the request, up to MaxBodySize, is read to check its Content-Digest, then restored.
+//
+// When the request has several signatures, the first one which verifies is accepted:
+// a signature added by a proxy under another label does not hide the signature of the client.
+func VerifyHTTPSignature(r *http.Request, opt HTTPSignatureOption) (string, error) {
+	if opt.Keys == nil {
+		return "", errSignatureKeysRequired
+	}
+	inputs, err := parseSignatureInput(r.Header.Get(headerSignatureInput))
+	if err != nil {
+		return "", err
+	}
+	signatures, err := parseSignatures(r.Header.Get(headerSignature))
+	if err != nil {
+		return "", err
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(nil, r.Body, opt.maxBodySize())
+	}
+	body, err := readRequestBody(r)
+	if err != nil {
+		return "", err
+	}
+
+	var firstErr error
+	for _, input := range inputs {
+		sig, ok := signatures[input.label]
+		if !ok {
+			continue
+		}
+		clientId, err := verifySignature(r, opt, input, sig, body)
+		if err == nil {
+			return clientId, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("%w: missing signature %s", ErrHTTPSignatureInvalid, inputs[0].label)
+	}
+	return "", firstErr
+}
+
+// verifySignature verifies the signature of one label of the request.
+func verifySignature(r *http.Request, opt HTTPSignatureOption, input signatureInput, sig, body []byte) (string, error) {
+	clientId, err := input.stringParam("keyid")
+	if err != nil {
+		return "", err
+	}
+	if err := input.checkTime(time.Now(), opt.maxAge()); err != nil {
+		return "", err
+	}
+
+	required := slices.Clone(defaultSignatureComponents)
+	if len(body) > 0 {
+		required = append(required, strings.ToLower(headerContentDigest))
+	}
+	if r.Header.Get(xApiClientId) != "" {
+		if r.Header.Get(xApiClientId) != clientId {
+			return "", fmt.Errorf("%w: %s does not match the key ID", ErrHTTPSignatureInvalid, xApiClientId)
+		}
+		required = append(required, strings.ToLower(xApiClientId))
+	}
+	for _, h := range opt.RequiredHeaders {
+		required = append(required, strings.ToLower(h))
+	}
+	for _, c := range required {
+		if !slices.Contains(input.components, c) {
+			return "", fmt.Errorf("%w: %q is not covered", ErrHTTPSignatureInvalid, c)
+		}
+	}
+
+	key, err := opt.Keys(r.Context(), clientId)
+	if err != nil {
+		return "", fmt.Errorf("%w: unknown key ID %q: %v", ErrHTTPSignatureInvalid, clientId, err)
+	}
+	alg, err := signatureAlgorithmOf(key)
+	if err != nil {
+		return "", err
+	}
+	if input.hasParam("alg") {
+		if a, err := input.stringParam("alg"); err != nil || a != alg {
+			return "", fmt.Errorf("%w: algorithm does not match the key", ErrHTTPSignatureInvalid)
+		}
+	}
+
+	base, err := signatureBase(r, input.signatureParams)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyMessage(alg, key, base, sig); err != nil {
+		return "", err
+	}
+	if slices.Contains(input.components, strings.ToLower(headerContentDigest)) {
+		if err := verifyContentDigest(r.Header.Get(headerContentDigest), body); err != nil {
+			return "", err
+		}
+	}
+	// The nonce is recorded once the signature verifies, a forged request cannot consume it
+	if opt.Nonces != nil {
+		nonce, err := input.stringParam("nonce")
+		if err != nil {
+			return "", err
+		}
+		seen, err := opt.Nonces.Seen(r.Context(), clientId+":"+nonce, time.Now().Add(opt.maxAge()+signatureLeeway))
+		if err != nil {
+			return "", fmt.Errorf("failed to check the signature nonce: %w", err)
+		}
+		if seen {
+			return "", fmt.Errorf("%w: nonce has already been used", ErrHTTPSignatureInvalid)
+		}
+	}
+	return clientId, nil
+}
+
+// signatureParams are the covered components and the parameters of a signature, in their order.
+type signatureParams struct {
+	components []string
+	// params are the name and the serialized value of the parameters
+	params [][2]string
+}
+
+// String serializes the parameters as the value of the "@signature-params" component (RFC 9421, section 2.3).
+func (p signatureParams) String() string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, c := range p.components {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.Quote(c))
+	}
+	b.WriteByte(')')
+	for _, param := range p.params {
+		b.WriteString(";" + param[0] + "=" + param[1])
+	}
+	return b.String()
+}
+
+func (p signatureParams) hasParam(name string) bool {
+	return slices.ContainsFunc(p.params, func(param [2]string) bool { return param[0] == name })
+}
+
+func (p signatureParams) param(name string) string {
+	for _, param := range p.params {
+		if param[0] == name {
+			return param[1]
+		}
+	}
+	return ""
+}
+
+func (p signatureParams) stringParam(name string) (string, error) {
+	val, err := strconv.Unquote(p.param(name))
+	if err != nil || val == "" {
+		return "", fmt.Errorf("%w: missing %s", ErrHTTPSignatureInvalid, name)
+	}
+	return val, nil
+}
+
+// checkTime checks the signature is created within maxAge and is not expired.
+func (p signatureParams) checkTime(now time.Time, maxAge time.Duration) error {
+	created, err := strconv.ParseInt(p.param("created"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing created", ErrHTTPSignatureInvalid)
+	}
+	if t := time.Unix(created, 0); t.After(now.Add(signatureLeeway)) || t.Add(maxAge+signatureLeeway).Before(now) {
+		return fmt.Errorf("%w: created is out of the acceptable window", ErrHTTPSignatureInvalid)
+	}
+	if p.hasParam("expires") {
+		expires, err := strconv.ParseInt(p.param("expires"), 10, 64)
+		if err != nil || time.Unix(expires, 0).Add(signatureLeeway).Before(now) {
+			return fmt.Errorf("%w: signature is expired", ErrHTTPSignatureInvalid)
+		}
+	}
+	return nil
+}
+
+// signatureInput is a member of the Signature-Input header.
+type signatureInput struct {
+	label string
+	signatureParams
+}
+
+// parseSignatureInput parses the Signature-Input header, a dictionary of inner lists (RFC 8941):
+//
+//	sig1=("@method" "@path" "content-digest");created=1618884473;keyid="partner-01";alg="ed25519"
+//
+// The components with parameters (e.g. "@query-param";name="id") are not supported.
+func parseSignatureInput(header string) ([]signatureInput, error) {
+	if header == "" {
+		return nil, fmt.Errorf("%w: missing %s header", ErrHTTPSignatureInvalid, headerSignatureInput)
+	}
+	malformed := fmt.Errorf("%w: malformed %s header", ErrHTTPSignatureInvalid, headerSignatureInput)
+
+	var inputs []signatureInput
+	s := header
+	for {
+		s = strings.TrimLeft(s, " \t")
+		label, rest, ok := strings.Cut(s, "=")
+		if !ok || label == "" || !strings.HasPrefix(rest, "(") {
+			return nil, malformed
+		}
+		input := signatureInput{label: label}
+		s = rest[1:]
+		// Inner list of strings
+		for {
+			s = strings.TrimLeft(s, " ")
+			if strings.HasPrefix(s, ")") {
+				s = s[1:]
+				break
+			}
+			c, n, err := parseString(s)
+			if err != nil {
+				return nil, malformed
+			}
+			input.components = append(input.components, c)
+			s = s[n:]
+			if strings.HasPrefix(s, ";") {
+				return nil, fmt.Errorf("%w: component parameters are not supported", ErrHTTPSignatureInvalid)
+			}
+		}
+		// Parameters: ;name=value where value is an integer or a string
+		for strings.HasPrefix(s, ";") {
+			name, rest, ok := strings.Cut(s[1:], "=")
+			if !ok || name == "" {
+				return nil, malformed
+			}
+			var n int
+			if strings.HasPrefix(rest, `"`) {
+				_, size, err := parseString(rest)
+				if err != nil {
+					return nil, malformed
+				}
+				n = size
+			} else {
+				n = strings.IndexAny(rest, ";, \t")
+				if n < 0 {
+					n = len(rest)
+				}
+			}
+			input.params = append(input.params, [2]string{name, rest[:n]})
+			s = rest[n:]
+		}
+		inputs = append(inputs, input)
+
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return inputs, nil
+		}
+		if !strings.HasPrefix(s, ",") {
+			return nil, malformed
+		}
+		s = s[1:]
+	}
+}
+
+// parseString parses the quoted string at the start of s, and returns its value and size.
+func parseString(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, errors.New("expected a string")
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 == len(s) || (s[i+1] != '"' && s[i+1] != '\\') {
+				return "", 0, errors.New("invalid escape")
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+// parseSignatures parses the Signature header, a dictionary of byte sequences: sig1=:<base64>:
+func parseSignatures(header string) (map[string][]byte, error) {
+	if header == "" {
+		return nil, fmt.Errorf("%w: missing %s header", ErrHTTPSignatureInvalid, headerSignature)
+	}
+	signatures := make(map[string][]byte)
+	for _, member := range strings.Split(header, ",") {
+		label, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			return nil, fmt.Errorf("%w: malformed %s header", ErrHTTPSignatureInvalid, headerSignature)
+		}
+		sig, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed %s header", ErrHTTPSignatureInvalid, headerSignature)
+		}
+		signatures[label] = sig
+	}
+	return signatures, nil
+}
+
+// signatureBase creates the signature base of the request (RFC 9421, section 2.5).
+func signatureBase(r *http.Request, params signatureParams) ([]byte, error) {
+	var b bytes.Buffer
+	for _, c := range params.components {
+		value, err := componentValue(r, c)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%q: %s\n", c, value)
+	}
+	fmt.Fprintf(&b, "%q: %s", "@signature-params", params.String())
+	return b.Bytes(), nil
+}
+
+// componentValue returns the value of a derived component (RFC 9421, section 2.2) or of a header field.
+func componentValue(r *http.Request, component string) (string, error) {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	switch component {
+	case "@method":
+		return r.Method, nil
+	case "@authority":
+		return strings.ToLower(host), nil
+	case "@path":
+		if path := r.URL.EscapedPath(); path != "" {
+			return path, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + r.URL.RawQuery, nil
+	}
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("%w: unsupported component %q", ErrHTTPSignatureInvalid, component)
+	}
+	values := r.Header.Values(component)
+	if len(values) == 0 {
+		return "", fmt.Errorf("%w: missing header %q", ErrHTTPSignatureInvalid, component)
+	}
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// signatureAlgorithmOf returns the algorithm of a signing or verification key.
+func signatureAlgorithmOf(key any) (string, error) {
+	switch k := key.(type) {
+	case []byte:
+		if len(k) == 0 {
+			return "", errors.New("hmac secret is empty")
+		}
+		return SignatureAlgHMACSHA256, nil
+	case ed25519.PublicKey, *edKeys.PublicKey:
+		return SignatureAlgEd25519, nil
+	case *rsa.PublicKey, *rsaKeys.PublicKey:
+		return SignatureAlgRSAPSSSHA512, nil
+	case crypto.Signer:
+		// Private keys and key pairs
+		return signatureAlgorithmOf(k.Public())
+	default:
+		return "", fmt.Errorf("unsupported signature key type: %T", key)
+	}
+}
+
+func signMessage(alg string, key any, message []byte) ([]byte, error) {
+	switch alg {
+	case SignatureAlgHMACSHA256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write(message)
+		return mac.Sum(nil), nil
+	case SignatureAlgEd25519:
+		return key.(crypto.Signer).Sign(rand.Reader, message, crypto.Hash(0))
+	case SignatureAlgRSAPSSSHA512:
+		digest := sha512.Sum512(message)
+		return key.(crypto.Signer).Sign(rand.Reader, digest[:], &rsa.PSSOptions{SaltLength: 64, Hash: crypto.SHA512})
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm: %s", alg)
+	}
+}
+
+func verifyMessage(alg string, key any, message, sig []byte) error {
+	var ok bool
+	switch alg {
+	case SignatureAlgHMACSHA256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write(message)
+		ok = hmac.Equal(mac.Sum(nil), sig)
+	case SignatureAlgEd25519:
+		pub, err := publicKeyOf(key)
+		if err != nil {
+			return err
+		}
+		ok = ed25519.Verify(pub.(ed25519.PublicKey), message, sig)
+	case SignatureAlgRSAPSSSHA512:
+		pub, err := publicKeyOf(key)
+		if err != nil {
+			return err
+		}
+		digest := sha512.Sum512(message)
+		ok = rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA512, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+	default:
+		return fmt.Errorf("unsupported signature algorithm: %s", alg)
+	}
+	if !ok {
+		return fmt.Errorf("%w: signature does not verify", ErrHTTPSignatureInvalid)
+	}
+	return nil
+}
+
+// publicKeyOf returns the crypto.PublicKey of a verification key.
+func publicKeyOf(key any) (crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *edKeys.PublicKey:
+		return k.PublicKey, nil
+	case *rsaKeys.PublicKey:
+		return k.PublicKey, nil
+	case crypto.Signer:
+		return k.Public(), nil
+	default:
+		return key, nil
+	}
+}
+
+// contentDigest returns the Content-Digest header of the body (RFC 9530).
+func contentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// verifyContentDigest checks the sha-256 or sha-512 digest of the Content-Digest header.
+func verifyContentDigest(header string, body []byte) error {
+	for _, member := range strings.Split(header, ",") {
+		alg, value, _ := strings.Cut(strings.TrimSpace(member), "=")
+		var sum []byte
+		switch alg {
+		case "sha-256":
+			s := sha256.Sum256(body)
+			sum = s[:]
+		case "sha-512":
+			s := sha512.Sum512(body)
+			sum = s[:]
+		default:
+			continue
+		}
+		if value == ":"+base64.StdEncoding.EncodeToString(sum)+":" {
+			return nil
+		}
+		return fmt.Errorf("%w: %s does not match the body", ErrHTTPSignatureInvalid, headerContentDigest)
+	}
+	return fmt.Errorf("%w: %s has no supported digest", ErrHTTPSignatureInvalid, headerContentDigest)
+}
+
+// readRequestBody reads the body of the request and restores it, so it can be read again.
+func readRequestBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,234 @@
+package net
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+// Test case B.2.6 of RFC 9421, signed with the key "test-key-ed25519"
+func TestHTTPSignatureSpecVector(t *testing.T) {
+	t.Parallel()
+
+	der, err := base64.StdEncoding.DecodeString("MCowBQYDK2VwAyEAJrQLj5P/89iXES9+vFgrIy29clF9CC/oPPsw3c5D0bs=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/foo?param=Value&Pet=dog", strings.NewReader(`{"hello": "world"}`))
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Length", "18")
+	inputs, err := parseSignatureInput(`sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signatures, err := parseSignatures(`sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base, err := signatureBase(req, inputs[0].signatureParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyMessage(SignatureAlgEd25519, pub.(ed25519.PublicKey), base, signatures["sig-b26"]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPSignatureMiddleware(t *testing.T) {
+	t.Parallel()
+
+	edPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPair, err := rsaKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	keys := map[string]any{
+		"partner-ed25519": edPair.ToPublic(),
+		"partner-rsa":     rsaPair.ToPublic(),
+		"partner-hmac":    secret,
+	}
+
+	ro := mux.NewRouter()
+	ro.HandleFunc("/transfers", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = WriteJSON(w, http.StatusOK, map[string]string{
+			"clientId": SignatureClientIdFromContext(r.Context()),
+			"body":     string(body),
+		})
+	})
+	ro.Use(HTTPSignatureMiddleware(HTTPSignatureOption{
+		Keys: func(ctx context.Context, clientId string) (any, error) {
+			if key, ok := keys[clientId]; ok {
+				return key, nil
+			}
+			return nil, errors.New("unknown client")
+		},
+		RequiredHeaders: []string{xApiRequestId},
+	}))
+	server := httptest.NewServer(ro)
+	defer server.Close()
+
+	for clientId, key := range map[string]any{"partner-ed25519": edPair, "partner-rsa": rsaPair, "partner-hmac": secret} {
+		t.Run(clientId, func(t *testing.T) {
+			signer, err := NewHTTPSigner(clientId, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: signer.SetHeaders(xApiRequestId).Transport(nil)}
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/transfers?dryRun=true", strings.NewReader(`{"amount":100}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set(xApiRequestId, "req-1")
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), clientId) || !strings.Contains(string(body), "amount") {
+				t.Fatalf("unexpected response %d: %s", resp.StatusCode, body)
+			}
+		})
+	}
+
+	signer, err := NewHTTPSigner("partner-ed25519", edPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(`{"amount":100}`))
+		req.Header.Set(xApiRequestId, "req-2")
+		if err := signer.SetHeaders(xApiRequestId).Sign(req); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *http.Request)
+	}{
+		{name: "missing signature", modify: func(req *http.Request) { req.Header.Del(headerSignature) }},
+		{name: "tampered body", modify: func(req *http.Request) { req.Body = io.NopCloser(strings.NewReader(`{"amount":999}`)) }},
+		{name: "tampered query", modify: func(req *http.Request) { req.URL.RawQuery = "dryRun=false" }},
+		{name: "other authority", modify: func(req *http.Request) { req.Host = "payments.example.com" }},
+		{name: "other client", modify: func(req *http.Request) { req.Header.Set(xApiClientId, "partner-rsa") }},
+		{name: "uncovered header", modify: func(req *http.Request) {
+			unsigned := httptest.NewRequest(http.MethodGet, "/transfers", nil)
+			if err := signer.Sign(unsigned); err != nil {
+				t.Fatal(err)
+			}
+			unsigned.Header.Set(xApiRequestId, "req-3")
+			*req = *unsigned
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			tt.modify(req)
+			rec := httptest.NewRecorder()
+			ro.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusUnauthorized, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	// The signature of the client is found after the signature of another label, e.g. added by a proxy
+	req := newRequest()
+	req.Header.Set(headerSignatureInput, `proxy=("@method");keyid="proxy", `+req.Header.Get(headerSignatureInput))
+	req.Header.Set(headerSignature, "proxy=:AAAA:, "+req.Header.Get(headerSignature))
+	rec := httptest.NewRecorder()
+	ro.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	// The body is read up to MaxBodySize
+	limited := HTTPSignatureMiddleware(HTTPSignatureOption{
+		Keys: func(ctx context.Context, clientId string) (any, error) {
+			return keys[clientId], nil
+		},
+		MaxBodySize: 8,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("the request must not be served")
+	}))
+	rec = httptest.NewRecorder()
+	limited.ServeHTTP(rec, newRequest())
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+	}
+}
+
+func TestHTTPSignatureNonce(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	signer, err := NewHTTPSigner("partner-hmac", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := HTTPSignatureOption{
+		Keys: func(ctx context.Context, clientId string) (any, error) {
+			return secret, nil
+		},
+		Nonces: jwt.NewMemoryReplayCache(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/transfers", nil)
+	if err := signer.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyHTTPSignature(req, opt); err != nil {
+		t.Fatal(err)
+	}
+	// The same signature is rejected the second time
+	if _, err := VerifyHTTPSignature(req, opt); !errors.Is(err, ErrHTTPSignatureInvalid) {
+		t.Fatalf("expected a replayed signature to be rejected, got %v", err)
+	}
+	// A new signature of the same request has another nonce
+	if err := signer.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyHTTPSignature(req, opt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPSignatureMiddlewareMisconfigured(t *testing.T) {
+	t.Parallel()
+
+	handler := HTTPSignatureMiddleware(HTTPSignatureOption{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("the request must not be served")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transfers", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
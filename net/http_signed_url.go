@@ -0,0 +1,296 @@
+package net
+
+import (
+	"crypto"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/golang-devkit/pkg/crypto/jwt"
+	"github.com/golang-devkit/pkg/logger"
+)
+
+const (
+	// Query parameters of the signed URLs
+	signedURLExpires    = "X-Expires"
+	signedURLKeyId      = "X-Key-Id"
+	signedURLClientId   = "X-Client-Id"
+	signedURLPathPrefix = "X-Path-Prefix"
+	signedURLSignature  = "X-Signature"
+)
+
+var (
+	// ErrSignedURLInvalid is returned when the signature of a signed URL is missing, malformed or does not verify.
+	ErrSignedURLInvalid = errors.New("invalid signed URL")
+	// ErrSignedURLExpired is returned when the signed URL has expired.
+	ErrSignedURLExpired = errors.New("signed URL has expired")
+)
+
+// URLSigner mints signed URLs which expire, e.g. time-limited download links to the statements served by FileServer.
+//
+// The signature covers the path (or the path prefix, see SetPathPrefix) and the whole query,
+// with the expiry, the key ID and the client ID added as query parameters.
+//
+// Example:
+//
+//	signer, err := net.NewURLSigner("downloads-2026", secret)
+//	link, err := signer.SetExpiry(10*time.Minute).SetClientId(userId).
+//		Sign("https://api.example.com/statements/2026-09.pdf")
+type URLSigner struct {
+	keyId      string
+	alg        string
+	key        any
+	expiry     time.Duration
+	clientId   string
+	pathPrefix string
+	now        func() time.Time
+}
+
+// NewURLSigner creates a signer of URLs valid for 15 minutes, with the key:
+//   - []byte, the secret shared with the server: hmac-sha256
+//   - ed25519.PrivateKey, *ed25519.KeyPair or a crypto.Signer of an Ed25519 key: ed25519
+//
+// The key ID selects the verification key on the server, see SignedURLOption.
+func NewURLSigner(keyId string, key any) (*URLSigner, error) {
+	if keyId == "" {
+		return nil, errors.New("key ID is required")
+	}
+	alg, err := signedURLAlgorithmOf(key)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := key.(crypto.Signer); !ok && alg != SignatureAlgHMACSHA256 {
+		return nil, fmt.Errorf("private key is required, got %T", key)
+	}
+	return &URLSigner{keyId: keyId, alg: alg, key: key, expiry: 15 * time.Minute, now: time.Now}, nil
+}
+
+// SetExpiry sets how long after their creation the URLs are valid.
+func (src *URLSigner) SetExpiry(d time.Duration) *URLSigner {
+	dst := *src
+	dst.expiry = d
+	return &dst
+}
+
+func (src *URLSigner) Expiry() time.Duration {
+	return src.expiry
+}
+
+// SetClientId binds the URLs to a client: they are only served to the requests of this client,
+// see SignedURLOption.ClientId.
+func (src *URLSigner) SetClientId(clientId string) *URLSigner {
+	dst := *src
+	dst.clientId = clientId
+	return &dst
+}
+
+func (src *URLSigner) ClientId() string {
+	return src.clientId
+}
+
+// SetPathPrefix makes the URLs valid for every path below the prefix (e.g. "/statements/2026/"),
+// instead of the path of the signed URL only. The path of the signed URL must be below the prefix.
+func (src *URLSigner) SetPathPrefix(prefix string) *URLSigner {
+	dst := *src
+	dst.pathPrefix = prefix
+	return &dst
+}
+
+func (src *URLSigner) PathPrefix() string {
+	return src.pathPrefix
+}
+
+func (src *URLSigner) KeyId() string {
+	return src.keyId
+}
+
+func (src *URLSigner) Algorithm() string {
+	return src.alg
+}
+
+// Sign returns the URL with the signature query parameters, the URL can be absolute or a path.
+func (s *URLSigner) Sign(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse URL: %w", err)
+	}
+	signedPath := cleanPath(u.Path)
+	if s.pathPrefix != "" {
+		if !strings.HasSuffix(s.pathPrefix, "/") {
+			return "", fmt.Errorf("path prefix %s must end with /", s.pathPrefix)
+		}
+		if !strings.HasPrefix(signedPath, s.pathPrefix) {
+			return "", fmt.Errorf("path %s is not below the prefix %s", signedPath, s.pathPrefix)
+		}
+		signedPath = s.pathPrefix
+	}
+
+	query := u.Query()
+	for _, param := range []string{signedURLExpires, signedURLKeyId, signedURLClientId, signedURLPathPrefix, signedURLSignature} {
+		query.Del(param)
+	}
+	query.Set(signedURLExpires, strconv.FormatInt(s.now().Add(s.expiry).Unix(), 10))
+	query.Set(signedURLKeyId, s.keyId)
+	if s.clientId != "" {
+		query.Set(signedURLClientId, s.clientId)
+	}
+	if s.pathPrefix != "" {
+		query.Set(signedURLPathPrefix, s.pathPrefix)
+	}
+
+	sig, err := signMessage(s.alg, s.key, signedURLBase(signedPath, query))
+	if err != nil {
+		return "", err
+	}
+	query.Set(signedURLSignature, base64.RawURLEncoding.EncodeToString(sig))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// SignedURLOption configures SignedURLHandler.
+type SignedURLOption struct {
+	// Keys looks up the verification key of the key ID of the URL: the secret ([]byte) of hmac-sha256,
+	// or the ed25519.PublicKey (or a PublicKey of the ed25519 package of this module).
+	Keys HTTPSignatureKeyFunc
+
+	// ClientId returns the client of the request, compared with the client the URL is bound to.
+	// By default the client ID of the HTTP message signature (SignatureClientIdFromContext),
+	// or the user ID of the JWT (jwt.UserIdFromContext): the handler must then run behind the authentication middleware.
+	ClientId func(r *http.Request) string
+}
+
+func (opt *SignedURLOption) clientId(r *http.Request) string {
+	if opt.ClientId != nil {
+		return opt.ClientId(r)
+	}
+	if clientId := SignatureClientIdFromContext(r.Context()); clientId != "" {
+		return clientId
+	}
+	return jwt.UserIdFromContext(r.Context())
+}
+
+// SignedURLHandler wraps the handler to serve only the requests to a valid signed URL, see URLSigner.
+//
+// It answers 403 Forbidden through WriteError when the signature is missing or invalid,
+// when the URL has expired, or when it is bound to another client,
+// and 500 Internal Server Error when the option has no Keys.
+//
+// Example:
+//
+//	ro.PathPrefix("/statements/").Handler(net.SignedURLHandler(net.FileServer("/statements/", "./statements"), net.SignedURLOption{
+//		Keys: func(ctx context.Context, keyId string) (any, error) {
+//			return downloadSecrets[keyId], nil
+//		},
+//	}))
+func SignedURLHandler(h http.Handler, opt SignedURLOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A misconfigured handler rejects every request rather than serving them unverified
+		if opt.Keys == nil {
+			getLoggerFromContext(r.Context()).Error("Signed URL verification is misconfigured",
+				zap.String(logger.KeyError, errSignatureKeysRequired.Error()))
+			WriteError(w, http.StatusInternalServerError, errSignatureKeysRequired)
+			return
+		}
+		if err := VerifySignedURL(r, opt); err != nil {
+			getLoggerFromContext(r.Context()).Warn("Signed URL verification failed",
+				zap.String("path", r.URL.Path),
+				zap.String(logger.KeyError, err.Error()))
+			WriteError(w, http.StatusForbidden, err)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// SignedFileServer is FileServer behind SignedURLHandler: the files are served to the signed URLs only.
+func SignedFileServer(prefix, dirPath string, opt SignedURLOption) http.Handler {
+	return SignedURLHandler(FileServer(prefix, dirPath), opt)
+}
+
+// VerifySignedURL verifies the signature, the expiry and the client binding of the URL of the request.
+func VerifySignedURL(r *http.Request, opt SignedURLOption) error {
+	if opt.Keys == nil {
+		return errSignatureKeysRequired
+	}
+	query := r.URL.Query()
+	sig, err := base64.RawURLEncoding.DecodeString(query.Get(signedURLSignature))
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("%w: missing or malformed %s", ErrSignedURLInvalid, signedURLSignature)
+	}
+	query.Del(signedURLSignature)
+
+	expires, err := strconv.ParseInt(query.Get(signedURLExpires), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed %s", ErrSignedURLInvalid, signedURLExpires)
+	}
+
+	// The path is cleaned as FileServer does, so "/prefix/../other" does not escape the prefix
+	signedPath := cleanPath(r.URL.Path)
+	if prefix := query.Get(signedURLPathPrefix); prefix != "" {
+		if !strings.HasPrefix(signedPath, prefix) {
+			return fmt.Errorf("%w: path is not below the signed prefix", ErrSignedURLInvalid)
+		}
+		signedPath = prefix
+	}
+
+	keyId := query.Get(signedURLKeyId)
+	key, err := opt.Keys(r.Context(), keyId)
+	if err != nil || key == nil {
+		return fmt.Errorf("%w: unknown key ID %q", ErrSignedURLInvalid, keyId)
+	}
+	alg, err := signedURLAlgorithmOf(key)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignedURLInvalid, err)
+	}
+	if err := verifyMessage(alg, key, signedURLBase(signedPath, query), sig); err != nil {
+		return fmt.Errorf("%w: signature does not verify", ErrSignedURLInvalid)
+	}
+
+	// The expiry and the client are checked once the signature proves them
+	if !time.Now().Before(time.Unix(expires, 0)) {
+		return ErrSignedURLExpired
+	}
+	if clientId := query.Get(signedURLClientId); clientId != "" && clientId != opt.clientId(r) {
+		return fmt.Errorf("%w: bound to another client", ErrSignedURLInvalid)
+	}
+	return nil
+}
+
+// signedURLAlgorithmOf returns the algorithm of a signing or verification key of the signed URLs,
+// hmac-sha256 or ed25519: a RSA signature would make the URLs several hundred characters longer.
+func signedURLAlgorithmOf(key any) (string, error) {
+	alg, err := signatureAlgorithmOf(key)
+	if err != nil {
+		return "", err
+	}
+	if alg != SignatureAlgHMACSHA256 && alg != SignatureAlgEd25519 {
+		return "", fmt.Errorf("unsupported signed URL algorithm: %s", alg)
+	}
+	return alg, nil
+}
+
+// signedURLBase returns the signed message: the path and the query without signature, in the sorted form of url.Values.Encode.
+func signedURLBase(path string, query url.Values) []byte {
+	return []byte(path + "\n" + query.Encode())
+}
+
+// cleanPath returns the canonical form of the path, as http.FileServer resolves it.
+func cleanPath(p string) string {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	cleaned := path.Clean(p)
+	// Keep the trailing slash of a directory
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
@@ -0,0 +1,144 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	edKeys "github.com/golang-devkit/pkg/crypto/ed25519"
+	rsaKeys "github.com/golang-devkit/pkg/crypto/rsa"
+)
+
+func TestSignedFileServer(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "2026"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"2026/09.pdf", "2026/10.pdf", "secret.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret := []byte("download-secret")
+	edPair, err := edKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPair, err := rsaKeys.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]any{"hmac": secret, "ed25519": edPair.ToPublic(), "rsa": rsaPair.ToPublic()}
+	handler := SignedFileServer("/statements/", dir, SignedURLOption{
+		Keys: func(ctx context.Context, keyId string) (any, error) {
+			if key, ok := keys[keyId]; ok {
+				return key, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+		ClientId: func(r *http.Request) string { return r.Header.Get(xApiClientId) },
+	})
+	get := func(link, clientId string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, link, nil)
+		if clientId != "" {
+			req.Header.Set(xApiClientId, clientId)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	hmacSigner, err := NewURLSigner("hmac", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSigner, err := NewURLSigner("ed25519", edPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewURLSigner("rsa", rsaPair); err == nil {
+		t.Fatal("expected an error for a RSA key")
+	}
+	for _, signer := range []*URLSigner{hmacSigner, edSigner} {
+		link, err := signer.Sign("https://api.example.com/statements/2026/09.pdf?download=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rec := get(link, ""); rec.Code != http.StatusOK || rec.Body.String() != "2026/09.pdf" {
+			t.Fatalf("%s: unexpected response %d: %s", signer.Algorithm(), rec.Code, rec.Body.String())
+		}
+	}
+
+	sign := func(signer *URLSigner, rawURL string) string {
+		link, err := signer.Sign(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return link
+	}
+	tamper := func(link, param, value string) string {
+		u, _ := url.Parse(link)
+		query := u.Query()
+		query.Set(param, value)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	valid := sign(hmacSigner, "/statements/2026/09.pdf")
+	bound := sign(hmacSigner.SetClientId("alice"), "/statements/2026/09.pdf")
+	prefixed := sign(hmacSigner.SetPathPrefix("/statements/2026/"), "/statements/2026/09.pdf")
+	expired := hmacSigner.SetExpiry(-time.Second)
+
+	tests := []struct {
+		name     string
+		link     string
+		clientId string
+		code     int
+	}{
+		{name: "unsigned", link: "/statements/2026/09.pdf", code: http.StatusForbidden},
+		{name: "other path", link: "/statements/2026/10.pdf?" + mustQuery(valid), code: http.StatusForbidden},
+		{name: "RSA key", link: tamper(valid, signedURLKeyId, "rsa"), code: http.StatusForbidden},
+		{name: "extended expiry", link: tamper(valid, signedURLExpires, "4102444800"), code: http.StatusForbidden},
+		{name: "expired", link: sign(expired, "/statements/2026/09.pdf"), code: http.StatusForbidden},
+		{name: "bound client", link: bound, clientId: "alice", code: http.StatusOK},
+		{name: "other client", link: bound, clientId: "bob", code: http.StatusForbidden},
+		{name: "below prefix", link: "/statements/2026/10.pdf?" + mustQuery(prefixed), code: http.StatusOK},
+		{name: "escaped prefix", link: "/statements/2026/../secret.txt?" + mustQuery(prefixed), code: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := get(tt.link, tt.clientId); rec.Code != tt.code {
+				t.Fatalf("expected status %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSignedURLHandlerMisconfigured(t *testing.T) {
+	t.Parallel()
+
+	handler := SignedURLHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("the request must not be served")
+	}), SignedURLOption{})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/statements/2026/09.pdf", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
+
+func mustQuery(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		panic(err)
+	}
+	return u.RawQuery
+}